@@ -1,11 +1,13 @@
 package chper
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"hash/crc32"
 	"sort"
 	"sync"
+	"sync/atomic"
 )
 
 /*
@@ -55,6 +57,10 @@ type CHash[Node any] struct {
 	option *chashOption[Node]
 
 	lock sync.RWMutex
+
+	// version is increased when membership changes, protected by lock
+	version uint64
+	lookups atomic.Uint64
 }
 
 type virtualNode[Node any] struct {
@@ -77,6 +83,11 @@ type chashOption[Node any] struct {
 	virtualNodeFactor int
 
 	weightSpecify func(node Node) int
+
+	// probes > 0 means Multi-Probe Consistent Hashing
+	probes int
+
+	countLookups bool
 }
 
 func (cho *chashOption[Node]) adaptVirtualNodeFactor(nodeSize int) {
@@ -84,6 +95,11 @@ func (cho *chashOption[Node]) adaptVirtualNodeFactor(nodeSize int) {
 		return
 	}
 
+	if cho.probes > 0 {
+		cho.virtualNodeFactor = 1
+		return
+	}
+
 	factor := 1500 / nodeSize
 	if factor < 5 {
 		factor = 5
@@ -131,9 +147,27 @@ func CHashOptionIndexer[Node any](indexer func(data []byte) uint32) chashOptionF
 	}
 }
 
+// CHashOptionMultiProbe enable Multi-Probe Consistent Hashing (Appleton and O'Reilly, 2015)
+// each node has only one point on the ring (if virtualNodeFactor is not specified),
+// each data is probed `probes` times, the node with the closest point wins
+// 21 probes get a peak-to-mean ratio about 1.05
+func CHashOptionMultiProbe[Node any](probes int) chashOptionFunc[Node] {
+	return func(co *chashOption[Node]) {
+		co.probes = probes
+	}
+}
+
+// CHashOptionCountLookups enable counting Hash and HashN calls for Lookups, default is false
+// it adds an atomic increment to every lookup
+func CHashOptionCountLookups[Node any](countLookups bool) chashOptionFunc[Node] {
+	return func(co *chashOption[Node]) {
+		co.countLookups = countLookups
+	}
+}
+
 func NewCHash[Node any](nodes []Node, options ...chashOptionFunc[Node]) (*CHash[Node], error) {
 	if len(nodes) == 0 {
-		return nil, fmt.Errorf("want at least one node")
+		return nil, ErrNoNode
 	}
 
 	option := defaultCHashOption[Node]()
@@ -176,18 +210,48 @@ func (ch *CHash[Node]) AddNode(node Node) error {
 	return err
 }
 
-func (ch *CHash[Node]) addNode(node Node, weight int, doSort bool) error {
-	if weight < 1 {
-		return fmt.Errorf("weight must be greater than zero")
+// AddNodeContext is the same as AddNode, it returns ctx.Err() if ctx is done before the node is added
+func (ch *CHash[Node]) AddNodeContext(ctx context.Context, node Node) error {
+	return ch.withContext(ctx, func() error {
+		return ch.addNode(node, ch.option.weightSpecify(node), true)
+	})
+}
+
+// AddNodeWithWeightContext is the same as AddNodeWithWeight, it returns ctx.Err() if ctx is done before the node is added
+func (ch *CHash[Node]) AddNodeWithWeightContext(ctx context.Context, node Node, weight int) error {
+	return ch.withContext(ctx, func() error {
+		return ch.addNode(node, weight, true)
+	})
+}
+
+// withContext run action with write lock if ctx is not done
+func (ch *CHash[Node]) withContext(ctx context.Context, action func() error) error {
+	if err := ctx.Err(); err != nil {
+		return err
 	}
 
+	ch.lock.Lock()
+	defer ch.lock.Unlock()
+
+	// ctx may be done while waiting for the lock
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return action()
+}
+
+func (ch *CHash[Node]) addNode(node Node, weight int, doSort bool) error {
 	realNodeName, err := ch.option.nodeNaming(node)
 	if err != nil {
-		return fmt.Errorf("nodeNaming fail, err : %w", err)
+		return &NodeNamingError{Err: err}
+	}
+	if weight < 1 {
+		return &NodeError{Name: realNodeName, Err: ErrBadWeight}
 	}
 	_, ok := ch.realNodeMap[realNodeName]
 	if ok {
-		return fmt.Errorf("node existed, name: %s", realNodeName)
+		return &NodeError{Name: realNodeName, Err: ErrNodeExisted}
 	}
 
 	rn := realNode[Node]{
@@ -236,14 +300,21 @@ func (ch *CHash[Node]) RemoveNode(node Node) error {
 	return err
 }
 
+// RemoveNodeContext is the same as RemoveNode, it returns ctx.Err() if ctx is done before the node is removed
+func (ch *CHash[Node]) RemoveNodeContext(ctx context.Context, node Node) error {
+	return ch.withContext(ctx, func() error {
+		return ch.removeNode(node)
+	})
+}
+
 func (ch *CHash[Node]) removeNode(node Node) error {
 	realNodeName, err := ch.option.nodeNaming(node)
 	if err != nil {
-		return fmt.Errorf("nodeNaming fail, err : %w", err)
+		return &NodeNamingError{Err: err}
 	}
 	realNode, ok := ch.realNodeMap[realNodeName]
 	if !ok {
-		return fmt.Errorf("node not exist, name: %s", realNodeName)
+		return &NodeError{Name: realNodeName, Err: ErrNodeNotExist}
 	}
 	delete(ch.realNodeMap, realNodeName)
 
@@ -255,6 +326,83 @@ func (ch *CHash[Node]) removeNode(node Node) error {
 	return nil
 }
 
+// Nodes return all nodes, name -> node
+func (ch *CHash[Node]) Nodes() map[string]Node {
+	ch.lock.RLock()
+	defer ch.lock.RUnlock()
+
+	nodes := make(map[string]Node, len(ch.realNodeMap))
+	for name, rn := range ch.realNodeMap {
+		nodes[name] = rn.node
+	}
+
+	return nodes
+}
+
+// ApplyChanges remove and add nodes in one batch, removes are applied first
+// nothing is changed if any node is invalid
+func (ch *CHash[Node]) ApplyChanges(adds, removes []Node) error {
+	ch.lock.Lock()
+	err := ch.applyChanges(adds, removes)
+	ch.lock.Unlock()
+
+	return err
+}
+
+// ApplyChangesContext is the same as ApplyChanges, it returns ctx.Err() if ctx is done before changes are applied
+func (ch *CHash[Node]) ApplyChangesContext(ctx context.Context, adds, removes []Node) error {
+	return ch.withContext(ctx, func() error {
+		return ch.applyChanges(adds, removes)
+	})
+}
+
+func (ch *CHash[Node]) applyChanges(adds, removes []Node) error {
+	exists := make(map[string]bool, len(ch.realNodeMap))
+	for name := range ch.realNodeMap {
+		exists[name] = true
+	}
+
+	for _, node := range removes {
+		name, err := ch.option.nodeNaming(node)
+		if err != nil {
+			return &NodeNamingError{Err: err}
+		}
+		if !exists[name] {
+			return &NodeError{Name: name, Err: ErrNodeNotExist}
+		}
+		delete(exists, name)
+	}
+	for _, node := range adds {
+		name, err := ch.option.nodeNaming(node)
+		if err != nil {
+			return &NodeNamingError{Err: err}
+		}
+		if exists[name] {
+			return &NodeError{Name: name, Err: ErrNodeExisted}
+		}
+		if ch.option.weightSpecify(node) < 1 {
+			return &NodeError{Name: name, Err: ErrBadWeight}
+		}
+		exists[name] = true
+	}
+
+	for _, node := range removes {
+		name, _ := ch.option.nodeNaming(node)
+		for index := range ch.realNodeMap[name].virtualNodeIndexs {
+			delete(ch.virtualNodeMap, index)
+		}
+		delete(ch.realNodeMap, name)
+	}
+	for _, node := range adds {
+		if err := ch.addNode(node, ch.option.weightSpecify(node), false); err != nil {
+			return err
+		}
+	}
+	ch.sortVirtualNode()
+
+	return nil
+}
+
 func (ch *CHash[Node]) Hash(data []byte) (Node, error) {
 	ch.lock.RLock()
 	node, err := ch.hash(data)
@@ -264,22 +412,107 @@ func (ch *CHash[Node]) Hash(data []byte) (Node, error) {
 }
 
 func (ch *CHash[Node]) hash(data []byte) (node Node, err error) {
+	if ch.option.countLookups {
+		ch.lookups.Add(1)
+	}
 	if len(ch.virtualNodeList) == 0 {
-		err = fmt.Errorf("zero node")
+		err = ErrZeroNode
 		return
 	}
 
-	return ch.find(ch.option.indexer(data)), nil
+	return ch.virtualNodeList[ch.position(data)].realNode.node, nil
+}
+
+// position return the index of the virtual node which data belongs to
+func (ch *CHash[Node]) position(data []byte) int {
+	index := ch.option.indexer(data)
+	if ch.option.probes <= 0 {
+		return ch.search(index)
+	}
+
+	// double hashing: probe i is index + i*step
+	step := fmix32(index) | 1
+
+	best, bestDistance := 0, uint32(0)
+	for i := 0; i < ch.option.probes; i++ {
+		probe := index + uint32(i)*step
+		pos := ch.search(probe)
+		distance := ch.virtualNodeList[pos].beginIndex - probe
+		if i == 0 || distance < bestDistance {
+			best, bestDistance = pos, distance
+		}
+	}
+
+	return best
+}
+
+// fmix32 is the finalizer of MurmurHash3, it derives the probe step from index without allocation
+func fmix32(h uint32) uint32 {
+	h ^= h >> 16
+	h *= 0x85ebca6b
+	h ^= h >> 13
+	h *= 0xc2b2ae35
+	h ^= h >> 16
+	return h
+}
+
+// HashN get at most n distinct nodes by data, the first one is the same as Hash
+// others are the following nodes on the ring clockwise
+func (ch *CHash[Node]) HashN(data []byte, n int) ([]Node, error) {
+	ch.lock.RLock()
+	rns, err := ch.hashN(data, n)
+	ch.lock.RUnlock()
+
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make([]Node, len(rns))
+	for i, rn := range rns {
+		nodes[i] = rn.node
+	}
+
+	return nodes, nil
+}
+
+func (ch *CHash[Node]) hashN(data []byte, n int) ([]realNode[Node], error) {
+	if ch.option.countLookups {
+		ch.lookups.Add(1)
+	}
+	if len(ch.virtualNodeList) == 0 {
+		return nil, ErrZeroNode
+	}
+	if n > len(ch.realNodeMap) {
+		n = len(ch.realNodeMap)
+	}
+
+	rns := make([]realNode[Node], 0, n)
+	seen := make(map[string]bool, n)
+	begin := ch.position(data)
+	for i := 0; i < len(ch.virtualNodeList) && len(rns) < n; i++ {
+		rn := ch.virtualNodeList[(begin+i)%len(ch.virtualNodeList)].realNode
+		if seen[rn.name] {
+			continue
+		}
+		seen[rn.name] = true
+		rns = append(rns, rn)
+	}
+
+	return rns, nil
 }
 
 func (ch *CHash[Node]) find(index uint32) (node Node) {
+	return ch.virtualNodeList[ch.search(index)].realNode.node
+}
+
+func (ch *CHash[Node]) search(index uint32) int {
 	i := sort.Search(len(ch.virtualNodeList), func(i int) bool {
 		return ch.virtualNodeList[i].beginIndex > index
 	})
 	if i == len(ch.virtualNodeList) {
 		i = 0
 	}
-	return ch.virtualNodeList[i].realNode.node
+	return i
 }
 
 func (ch *CHash[Node]) sortVirtualNode() {
@@ -290,6 +523,7 @@ func (ch *CHash[Node]) sortVirtualNode() {
 	sort.Sort(virtualNodeSlice[Node](list))
 
 	ch.virtualNodeList = list
+	ch.version++
 }
 
 type virtualNodeSlice[Node any] []*virtualNode[Node]
@@ -297,3 +531,33 @@ type virtualNodeSlice[Node any] []*virtualNode[Node]
 func (x virtualNodeSlice[Node]) Len() int           { return len(x) }
 func (x virtualNodeSlice[Node]) Less(i, j int) bool { return x[i].beginIndex < x[j].beginIndex }
 func (x virtualNodeSlice[Node]) Swap(i, j int)      { x[i], x[j] = x[j], x[i] }
+
+// CHashStats is a snapshot of CHash's membership
+type CHashStats struct {
+	Nodes        int
+	VirtualNodes int
+}
+
+// Stats return the snapshot of CHash's membership
+func (ch *CHash[Node]) Stats() CHashStats {
+	ch.lock.RLock()
+	defer ch.lock.RUnlock()
+
+	return CHashStats{
+		Nodes:        len(ch.realNodeMap),
+		VirtualNodes: len(ch.virtualNodeList),
+	}
+}
+
+// Version return the membership version, it is increased every time nodes are changed
+func (ch *CHash[Node]) Version() uint64 {
+	ch.lock.RLock()
+	defer ch.lock.RUnlock()
+
+	return ch.version
+}
+
+// Lookups return the total count of Hash and HashN, it is always 0 without CHashOptionCountLookups
+func (ch *CHash[Node]) Lookups() uint64 {
+	return ch.lookups.Load()
+}
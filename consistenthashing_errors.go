@@ -0,0 +1,67 @@
+package chper
+
+import (
+	"errors"
+	"fmt"
+)
+
+// errors returned by CHash and CHashRouter, use errors.Is to check them
+var (
+	ErrNoNode       = errors.New("want at least one node")
+	ErrZeroNode     = errors.New("zero node")
+	ErrBadWeight    = errors.New("weight must be greater than zero")
+	ErrNodeNaming   = errors.New("nodeNaming fail")
+	ErrNodeExisted  = errors.New("node existed")
+	ErrNodeNotExist = errors.New("node not exist")
+	ErrPoolExisted  = errors.New("pool existed")
+	ErrPoolNotExist = errors.New("pool not exist")
+	ErrNoTenantPool = errors.New("no pool for tenant")
+)
+
+// NodeError carry the node name, use errors.As to get it
+// Err is one of the sentinel errors
+type NodeError struct {
+	Name string
+	Err  error
+}
+
+func (e *NodeError) Error() string {
+	return fmt.Sprintf("%v, name: %s", e.Err, e.Name)
+}
+
+func (e *NodeError) Unwrap() error {
+	return e.Err
+}
+
+// NodeNamingError is returned when nodeNaming fail
+// it matches ErrNodeNaming, and unwraps to the nodeNaming's error
+type NodeNamingError struct {
+	Err error
+}
+
+func (e *NodeNamingError) Error() string {
+	return fmt.Sprintf("%v, err : %v", ErrNodeNaming, e.Err)
+}
+
+func (e *NodeNamingError) Unwrap() error {
+	return e.Err
+}
+
+func (e *NodeNamingError) Is(target error) bool {
+	return target == ErrNodeNaming
+}
+
+// PoolError carry the pool or tenant name, use errors.As to get it
+// Err is one of the sentinel errors
+type PoolError struct {
+	Name string
+	Err  error
+}
+
+func (e *PoolError) Error() string {
+	return fmt.Sprintf("%v, name: %s", e.Err, e.Name)
+}
+
+func (e *PoolError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,137 @@
+package chper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestCHashErrors(t *testing.T) {
+	naming := CHashOptionNodeNaming[*Node](func(node *Node) (string, error) {
+		if node.Name == "" {
+			return "", fmt.Errorf("empty name")
+		}
+		return node.Name, nil
+	})
+
+	if _, err := NewCHash[*Node](nil, naming); !errors.Is(err, ErrNoNode) {
+		t.Errorf("want: %v, got: %v", ErrNoNode, err)
+	}
+
+	ch, err := NewCHash([]*Node{nodeA}, naming)
+	if err != nil {
+		t.Errorf("want nil, got: %v", err)
+		return
+	}
+
+	for _, cas := range []struct {
+		name     string
+		err      error
+		want     error
+		wantName string
+	}{
+		{name: "existed", err: ch.AddNode(nodeA), want: ErrNodeExisted, wantName: "A"},
+		{name: "not exist", err: ch.RemoveNode(nodeB), want: ErrNodeNotExist, wantName: "B"},
+		{name: "bad weight", err: ch.AddNodeWithWeight(nodeC, 0), want: ErrBadWeight, wantName: "C"},
+	} {
+		if !errors.Is(cas.err, cas.want) {
+			t.Errorf("%s, want: %v, got: %v", cas.name, cas.want, cas.err)
+		}
+		var nodeErr *NodeError
+		if !errors.As(cas.err, &nodeErr) || nodeErr.Name != cas.wantName {
+			t.Errorf("%s, want NodeError with name %s, got: %v", cas.name, cas.wantName, cas.err)
+		}
+	}
+
+	// naming error
+	{
+		err := ch.AddNode(&Node{})
+		if !errors.Is(err, ErrNodeNaming) {
+			t.Errorf("want: %v, got: %v", ErrNodeNaming, err)
+		}
+		var namingErr *NodeNamingError
+		if !errors.As(err, &namingErr) || namingErr.Err.Error() != "empty name" {
+			t.Errorf("want NodeNamingError, got: %v", err)
+		}
+		if got, want := err.Error(), "nodeNaming fail, err : empty name"; got != want {
+			t.Errorf("want: %v, got: %v", want, got)
+		}
+	}
+
+	ch.RemoveNode(nodeA)
+	if _, err := ch.Hash([]byte("1")); !errors.Is(err, ErrZeroNode) {
+		t.Errorf("want: %v, got: %v", ErrZeroNode, err)
+	}
+	if _, err := ch.HashN([]byte("1"), 2); !errors.Is(err, ErrZeroNode) {
+		t.Errorf("want: %v, got: %v", ErrZeroNode, err)
+	}
+}
+
+func TestCHashContext(t *testing.T) {
+	ch, err := NewCHash([]*Node{nodeA},
+		CHashOptionNodeNaming[*Node](func(node *Node) (string, error) { return node.Name, nil }),
+	)
+	if err != nil {
+		t.Errorf("want nil, got: %v", err)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := ch.AddNodeContext(ctx, nodeB); err != nil {
+		t.Errorf("want nil, got: %v", err)
+	}
+	if err := ch.AddNodeWithWeightContext(ctx, nodeC, 2); err != nil {
+		t.Errorf("want nil, got: %v", err)
+	}
+	if err := ch.RemoveNodeContext(ctx, nodeB); err != nil {
+		t.Errorf("want nil, got: %v", err)
+	}
+	if err := ch.RemoveNodeContext(ctx, nodeB); !errors.Is(err, ErrNodeNotExist) {
+		t.Errorf("want: %v, got: %v", ErrNodeNotExist, err)
+	}
+
+	cancel()
+	if err := ch.AddNodeContext(ctx, nodeD); !errors.Is(err, context.Canceled) {
+		t.Errorf("want: %v, got: %v", context.Canceled, err)
+	}
+	if err := ch.AddNodeWithWeightContext(ctx, nodeD, 1); !errors.Is(err, context.Canceled) {
+		t.Errorf("want: %v, got: %v", context.Canceled, err)
+	}
+	if err := ch.RemoveNodeContext(ctx, nodeA); !errors.Is(err, context.Canceled) {
+		t.Errorf("want: %v, got: %v", context.Canceled, err)
+	}
+	if got := ch.Stats().Nodes; got != 2 {
+		t.Errorf("want: 2, got: %v", got)
+	}
+}
+
+func TestCHashRouterErrors(t *testing.T) {
+	naming := CHashOptionNodeNaming[*Node](func(node *Node) (string, error) { return node.Name, nil })
+	cr := NewCHashRouter[*Node]()
+	cr.AddPool("p1", []*Node{nodeA}, naming)
+
+	for _, cas := range []struct {
+		name     string
+		err      error
+		want     error
+		wantName string
+	}{
+		{name: "existed", err: cr.AddPool("p1", []*Node{nodeA}, naming), want: ErrPoolExisted, wantName: "p1"},
+		{name: "not exist", err: cr.RemovePool("p2"), want: ErrPoolNotExist, wantName: "p2"},
+		{name: "move", err: cr.MoveTenant("t1", "p2"), want: ErrPoolNotExist, wantName: "p2"},
+		{name: "no pool", err: func() error { _, err := cr.Hash([]byte("t1/key")); return err }(), want: ErrNoTenantPool, wantName: "t1"},
+	} {
+		if !errors.Is(cas.err, cas.want) {
+			t.Errorf("%s, want: %v, got: %v", cas.name, cas.want, cas.err)
+		}
+		var poolErr *PoolError
+		if !errors.As(cas.err, &poolErr) || poolErr.Name != cas.wantName {
+			t.Errorf("%s, want PoolError with name %s, got: %v", cas.name, cas.wantName, cas.err)
+		}
+	}
+
+	if err := cr.AddPool("p2", nil, naming); !errors.Is(err, ErrNoNode) {
+		t.Errorf("want: %v, got: %v", ErrNoNode, err)
+	}
+}
@@ -0,0 +1,237 @@
+package chper
+
+import (
+	"container/heap"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+)
+
+// HotKeyCHash wraps CHash to spread hot keys
+// key frequency is counted by a bounded space-saving counter,
+// for keys whose guaranteed count (Count-Error) reach threshold, one of the first `replicas` nodes on the ring
+// is chosen by power-of-two-choices instead of always the primary node
+type HotKeyCHash[Node any] struct {
+	ch *CHash[Node]
+
+	counter *spaceSaving
+	// load is node name -> hot key lookups routed to it
+	load map[string]uint64
+
+	option *hotKeyOption
+
+	lock sync.Mutex
+}
+
+type hotKeyOption struct {
+	capacity  int
+	threshold uint64
+	replicas  int
+	random    *rand.Rand
+}
+
+func defaultHotKeyOption() *hotKeyOption {
+	return &hotKeyOption{
+		capacity:  128,
+		threshold: 1000,
+		replicas:  3,
+		random:    rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+type hotKeyOptionFunc func(*hotKeyOption)
+
+// HotKeyOptionCapacity specify how many keys are counted, default is 128
+func HotKeyOptionCapacity(capacity int) hotKeyOptionFunc {
+	return func(ho *hotKeyOption) {
+		ho.capacity = capacity
+	}
+}
+
+// HotKeyOptionThreshold specify the count from which a key is hot, default is 1000
+func HotKeyOptionThreshold(threshold uint64) hotKeyOptionFunc {
+	return func(ho *hotKeyOption) {
+		ho.threshold = threshold
+	}
+}
+
+// HotKeyOptionReplicas specify how many nodes a hot key can be spread to, default is 3
+func HotKeyOptionReplicas(replicas int) hotKeyOptionFunc {
+	return func(ho *hotKeyOption) {
+		ho.replicas = replicas
+	}
+}
+
+// HotKeyOptionRandSource specify the random source of power-of-two-choices
+func HotKeyOptionRandSource(source rand.Source) hotKeyOptionFunc {
+	return func(ho *hotKeyOption) {
+		ho.random = rand.New(source)
+	}
+}
+
+func NewHotKeyCHash[Node any](ch *CHash[Node], options ...hotKeyOptionFunc) *HotKeyCHash[Node] {
+	option := defaultHotKeyOption()
+	for _, f := range options {
+		f(option)
+	}
+	if option.capacity < 1 {
+		panic("bad capacity")
+	}
+	if option.replicas < 1 {
+		panic("bad replicas")
+	}
+
+	return &HotKeyCHash[Node]{
+		ch:      ch,
+		counter: newSpaceSaving(option.capacity),
+		load:    map[string]uint64{},
+		option:  option,
+	}
+}
+
+// Hash count data and get node by data
+// hot data may be routed to one of its replicas
+func (hc *HotKeyCHash[Node]) Hash(data []byte) (node Node, err error) {
+	// the lock only covers counting and load, lookups run without it
+	hc.lock.Lock()
+	c := hc.counter.add(string(data))
+	hc.lock.Unlock()
+
+	if c.count-c.error < hc.option.threshold || hc.option.replicas == 1 {
+		return hc.ch.Hash(data)
+	}
+
+	hc.ch.lock.RLock()
+	rns, err := hc.ch.hashN(data, hc.option.replicas)
+	hc.ch.lock.RUnlock()
+	if err != nil {
+		return
+	}
+
+	hc.lock.Lock()
+	defer hc.lock.Unlock()
+
+	picked := rns[0]
+	if len(rns) > 1 {
+		i := hc.option.random.Intn(len(rns))
+		j := hc.option.random.Intn(len(rns) - 1)
+		if j >= i {
+			j++
+		}
+
+		picked = rns[i]
+		if hc.load[rns[j].name] < hc.load[picked.name] {
+			picked = rns[j]
+		}
+	}
+	hc.load[picked.name]++
+
+	return picked.node, nil
+}
+
+// HotKey is a counted key
+// the real count is in [Count-Error, Count]
+type HotKey struct {
+	Key   string
+	Count uint64
+	Error uint64
+}
+
+// HotKeys return keys whose guaranteed count reach threshold, sorted by count desc
+func (hc *HotKeyCHash[Node]) HotKeys() []HotKey {
+	hc.lock.Lock()
+	defer hc.lock.Unlock()
+
+	keys := []HotKey{}
+	for _, c := range hc.counter.counters {
+		if c.count-c.error >= hc.option.threshold {
+			keys = append(keys, HotKey{Key: c.key, Count: c.count, Error: c.error})
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].Count != keys[j].Count {
+			return keys[i].Count > keys[j].Count
+		}
+		return keys[i].Key < keys[j].Key
+	})
+
+	return keys
+}
+
+// Reset forget all counted keys and node loads
+func (hc *HotKeyCHash[Node]) Reset() {
+	hc.lock.Lock()
+	hc.counter = newSpaceSaving(hc.option.capacity)
+	hc.load = map[string]uint64{}
+	hc.lock.Unlock()
+}
+
+// spaceSaving is the Space-Saving algorithm (Metwally et al.)
+// counters is a min heap by count, the smallest counter is replaced when full
+type spaceSaving struct {
+	capacity int
+	index    map[string]*spaceSavingCounter
+	counters spaceSavingHeap
+}
+
+type spaceSavingCounter struct {
+	key   string
+	count uint64
+	error uint64
+
+	heapIndex int
+}
+
+func newSpaceSaving(capacity int) *spaceSaving {
+	return &spaceSaving{
+		capacity: capacity,
+		index:    make(map[string]*spaceSavingCounter, capacity),
+		counters: make(spaceSavingHeap, 0, capacity),
+	}
+}
+
+// add count key once, return its counter
+func (ss *spaceSaving) add(key string) spaceSavingCounter {
+	if c, ok := ss.index[key]; ok {
+		c.count++
+		heap.Fix(&ss.counters, c.heapIndex)
+		return *c
+	}
+
+	if len(ss.counters) < ss.capacity {
+		c := &spaceSavingCounter{key: key, count: 1}
+		ss.index[key] = c
+		heap.Push(&ss.counters, c)
+		return *c
+	}
+
+	c := ss.counters[0]
+	delete(ss.index, c.key)
+	c.key, c.error = key, c.count
+	c.count++
+	ss.index[key] = c
+	heap.Fix(&ss.counters, 0)
+
+	return *c
+}
+
+type spaceSavingHeap []*spaceSavingCounter
+
+func (h spaceSavingHeap) Len() int           { return len(h) }
+func (h spaceSavingHeap) Less(i, j int) bool { return h[i].count < h[j].count }
+func (h spaceSavingHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].heapIndex, h[j].heapIndex = i, j
+}
+func (h *spaceSavingHeap) Push(x any) {
+	c := x.(*spaceSavingCounter)
+	c.heapIndex = len(*h)
+	*h = append(*h, c)
+}
+func (h *spaceSavingHeap) Pop() any {
+	old := *h
+	c := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return c
+}
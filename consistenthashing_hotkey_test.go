@@ -0,0 +1,147 @@
+package chper
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestSpaceSaving(t *testing.T) {
+	ss := newSpaceSaving(2)
+
+	for _, cas := range []struct {
+		key  string
+		want uint64
+	}{
+		{key: "a", want: 1},
+		{key: "a", want: 2},
+		{key: "b", want: 1},
+		{key: "c", want: 2}, // replace b
+		{key: "a", want: 3},
+		{key: "b", want: 3}, // replace c
+	} {
+		if got := ss.add(cas.key).count; got != cas.want {
+			t.Errorf("key: %s, want: %d, got: %d", cas.key, cas.want, got)
+		}
+	}
+
+	if len(ss.index) != 2 || ss.index["c"] != nil {
+		t.Errorf("want a and b, got: %v", ss.index)
+	}
+	if got := ss.index["b"].error; got != 2 {
+		t.Errorf("want: 2, got: %d", got)
+	}
+}
+
+func TestHotKeyCHash(t *testing.T) {
+	nodes := []*Node{}
+	for i := 0; i < 10; i++ {
+		nodes = append(nodes, &Node{Name: fmt.Sprint(i)})
+	}
+	ch, err := NewCHash(nodes,
+		CHashOptionNodeNaming[*Node](func(node *Node) (string, error) { return node.Name, nil }),
+	)
+	if err != nil {
+		t.Errorf("want nil, got: %v", err)
+		return
+	}
+
+	hc := NewHotKeyCHash(ch,
+		HotKeyOptionCapacity(16),
+		HotKeyOptionThreshold(100),
+		HotKeyOptionReplicas(3),
+		HotKeyOptionRandSource(rand.NewSource(1)),
+	)
+
+	// zipf traffic, key 0 is the hottest
+	zipf := rand.NewZipf(rand.New(rand.NewSource(1)), 1.5, 1, 999)
+	hotNodes := map[string]int{}
+	for i := 0; i < 20000; i++ {
+		key := fmt.Sprint(zipf.Uint64())
+		node, err := hc.Hash([]byte(key))
+		if err != nil {
+			t.Errorf("want nil, got: %v", err)
+			return
+		}
+		if key == "0" {
+			hotNodes[node.Name]++
+		}
+	}
+
+	replicas, _ := ch.HashN([]byte("0"), 3)
+	want := SliceMap(replicas, func(_ int, n *Node) string { return n.Name })
+	got := MapKeys(hotNodes)
+	SliceSort(want)
+	SliceSort(got)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want: %v, got: %v", want, got)
+	}
+	for name, count := range hotNodes {
+		if primary := hotNodes[replicas[0].Name]; count*3 < primary {
+			t.Errorf("node %s is not spread, count: %d, primary: %d", name, count, primary)
+		}
+	}
+
+	// cold key always go to primary
+	for i := 0; i < 5; i++ {
+		want, _ := ch.Hash([]byte("cold"))
+		got, _ := hc.Hash([]byte("cold"))
+		if got != want {
+			t.Errorf("want: %v, got: %v", want, got)
+		}
+	}
+
+	hotKeys := hc.HotKeys()
+	if len(hotKeys) == 0 || hotKeys[0].Key != "0" {
+		t.Errorf("want key 0 is the hottest, got: %v", hotKeys)
+	}
+	for i, hk := range hotKeys {
+		if hk.Count-hk.Error < 100 {
+			t.Errorf("want count >= 100, got: %v", hk)
+		}
+		if i > 0 && hk.Count > hotKeys[i-1].Count {
+			t.Errorf("want sorted desc, got: %v", hotKeys)
+		}
+	}
+
+	hc.Reset()
+	if got := hc.HotKeys(); len(got) != 0 {
+		t.Errorf("want empty, got: %v", got)
+	}
+}
+
+func TestHotKeyCHashConcurrent(t *testing.T) {
+	ch, err := NewCHash([]*Node{nodeA, nodeB, nodeC, nodeD},
+		CHashOptionNodeNaming[*Node](func(node *Node) (string, error) { return node.Name, nil }),
+	)
+	if err != nil {
+		t.Errorf("want nil, got: %v", err)
+		return
+	}
+	hc := NewHotKeyCHash(ch, HotKeyOptionThreshold(10), HotKeyOptionRandSource(rand.NewSource(1)))
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				key := "hot"
+				if j%2 == 0 {
+					key = fmt.Sprint(i, j)
+				}
+				if _, err := hc.Hash([]byte(key)); err != nil {
+					t.Errorf("want nil, got: %v", err)
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if hotKeys := hc.HotKeys(); len(hotKeys) == 0 || hotKeys[0].Key != "hot" {
+		t.Errorf("want key hot is the hottest, got: %v", hotKeys)
+	}
+}
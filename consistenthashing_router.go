@@ -0,0 +1,223 @@
+package chper
+
+import (
+	"bytes"
+	"sync"
+)
+
+// CHashRouter routes data to tenant scoped CHash pools
+// every pool is a CHash, tenant is resolved from data and mapped to a pool
+// the pool is chosen by: tenant assignment > pool named as tenant > fallback pool
+type CHashRouter[Node any] struct {
+	// pools is pool name -> CHash
+	pools map[string]*CHash[Node]
+	// tenantPools is tenant -> pool name
+	tenantPools map[string]string
+
+	option *chashRouterOption
+
+	lock sync.RWMutex
+}
+
+type chashRouterOption struct {
+	fallbackPool   string
+	tenantResolver func(data []byte) (tenant string, ok bool)
+}
+
+func defaultCHashRouterOption() *chashRouterOption {
+	return &chashRouterOption{
+		tenantResolver: keyPrefixTenantResolver([]byte("/")),
+	}
+}
+
+func keyPrefixTenantResolver(separator []byte) func(data []byte) (string, bool) {
+	return func(data []byte) (string, bool) {
+		i := bytes.Index(data, separator)
+		if i < 0 {
+			return "", false
+		}
+
+		return string(data[:i]), true
+	}
+}
+
+type chashRouterOptionFunc func(*chashRouterOption)
+
+// CHashRouterOptionFallbackPool specify the pool used when tenant has no pool
+func CHashRouterOptionFallbackPool(pool string) chashRouterOptionFunc {
+	return func(co *chashRouterOption) {
+		co.fallbackPool = pool
+	}
+}
+
+// CHashRouterOptionKeyPrefix resolve tenant by the data prefix before separator
+// default separator is "/"
+func CHashRouterOptionKeyPrefix(separator string) chashRouterOptionFunc {
+	return func(co *chashRouterOption) {
+		co.tenantResolver = keyPrefixTenantResolver([]byte(separator))
+	}
+}
+
+// CHashRouterOptionTenantResolver resolve tenant by user-defined function
+func CHashRouterOptionTenantResolver(resolver func(data []byte) (tenant string, ok bool)) chashRouterOptionFunc {
+	return func(co *chashRouterOption) {
+		co.tenantResolver = resolver
+	}
+}
+
+func NewCHashRouter[Node any](options ...chashRouterOptionFunc) *CHashRouter[Node] {
+	option := defaultCHashRouterOption()
+	for _, f := range options {
+		f(option)
+	}
+
+	return &CHashRouter[Node]{
+		pools:       map[string]*CHash[Node]{},
+		tenantPools: map[string]string{},
+		option:      option,
+	}
+}
+
+// AddPool create a pool, options are the same as NewCHash
+func (cr *CHashRouter[Node]) AddPool(pool string, nodes []Node, options ...chashOptionFunc[Node]) error {
+	ch, err := NewCHash(nodes, options...)
+	if err != nil {
+		return err
+	}
+
+	cr.lock.Lock()
+	defer cr.lock.Unlock()
+
+	if _, ok := cr.pools[pool]; ok {
+		return &PoolError{Name: pool, Err: ErrPoolExisted}
+	}
+	cr.pools[pool] = ch
+
+	return nil
+}
+
+// RemovePool remove a pool, tenants assigned to it will use the fallback pool
+func (cr *CHashRouter[Node]) RemovePool(pool string) error {
+	cr.lock.Lock()
+	defer cr.lock.Unlock()
+
+	if _, ok := cr.pools[pool]; !ok {
+		return &PoolError{Name: pool, Err: ErrPoolNotExist}
+	}
+	delete(cr.pools, pool)
+
+	for tenant, p := range cr.tenantPools {
+		if p == pool {
+			delete(cr.tenantPools, tenant)
+		}
+	}
+
+	return nil
+}
+
+// Pool return the pool's CHash, it can be used to add or remove nodes
+func (cr *CHashRouter[Node]) Pool(pool string) (*CHash[Node], bool) {
+	cr.lock.RLock()
+	defer cr.lock.RUnlock()
+
+	ch, ok := cr.pools[pool]
+	return ch, ok
+}
+
+// MoveTenant assign tenant to pool, the pool must exist
+func (cr *CHashRouter[Node]) MoveTenant(tenant, pool string) error {
+	cr.lock.Lock()
+	defer cr.lock.Unlock()
+
+	if _, ok := cr.pools[pool]; !ok {
+		return &PoolError{Name: pool, Err: ErrPoolNotExist}
+	}
+	cr.tenantPools[tenant] = pool
+
+	return nil
+}
+
+// ResetTenant remove tenant's assignment
+func (cr *CHashRouter[Node]) ResetTenant(tenant string) {
+	cr.lock.Lock()
+	delete(cr.tenantPools, tenant)
+	cr.lock.Unlock()
+}
+
+// TenantPool return the pool name which tenant routed to
+func (cr *CHashRouter[Node]) TenantPool(tenant string) (string, bool) {
+	cr.lock.RLock()
+	defer cr.lock.RUnlock()
+
+	pool, _, ok := cr.tenantPool(tenant, true)
+	return pool, ok
+}
+
+func (cr *CHashRouter[Node]) tenantPool(tenant string, resolved bool) (string, *CHash[Node], bool) {
+	if resolved {
+		if pool, ok := cr.tenantPools[tenant]; ok {
+			return pool, cr.pools[pool], true
+		}
+		if ch, ok := cr.pools[tenant]; ok {
+			return tenant, ch, true
+		}
+	}
+
+	ch, ok := cr.pools[cr.option.fallbackPool]
+	return cr.option.fallbackPool, ch, ok
+}
+
+// Hash resolve tenant from data, and get node from tenant's pool
+func (cr *CHashRouter[Node]) Hash(data []byte) (node Node, err error) {
+	tenant, ok := cr.option.tenantResolver(data)
+
+	return cr.hash(tenant, ok, data)
+}
+
+// HashTenant get node from tenant's pool
+func (cr *CHashRouter[Node]) HashTenant(tenant string, data []byte) (node Node, err error) {
+	return cr.hash(tenant, true, data)
+}
+
+func (cr *CHashRouter[Node]) hash(tenant string, resolved bool, data []byte) (node Node, err error) {
+	cr.lock.RLock()
+	_, ch, ok := cr.tenantPool(tenant, resolved)
+	cr.lock.RUnlock()
+
+	if !ok {
+		err = &PoolError{Name: tenant, Err: ErrNoTenantPool}
+		return
+	}
+
+	return ch.Hash(data)
+}
+
+// CHashRouterStats is a snapshot of all pools
+type CHashRouterStats struct {
+	// Pools is pool name -> pool stats
+	Pools map[string]CHashStats
+	// Tenants is tenant -> pool name
+	Tenants map[string]string
+
+	Nodes        int
+	VirtualNodes int
+}
+
+// Stats return the combined stats of all pools
+func (cr *CHashRouter[Node]) Stats() CHashRouterStats {
+	cr.lock.RLock()
+	defer cr.lock.RUnlock()
+
+	stats := CHashRouterStats{
+		Pools:   make(map[string]CHashStats, len(cr.pools)),
+		Tenants: MapShallowCopy(cr.tenantPools, func(string, string) bool { return true }),
+	}
+	for name, ch := range cr.pools {
+		s := ch.Stats()
+		stats.Pools[name] = s
+		stats.Nodes += s.Nodes
+		stats.VirtualNodes += s.VirtualNodes
+	}
+
+	return stats
+}
@@ -0,0 +1,155 @@
+package chper
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCHashRouter(t *testing.T) {
+	naming := CHashOptionNodeNaming[*Node](func(node *Node) (string, error) { return node.Name, nil })
+
+	cr := NewCHashRouter[*Node](CHashRouterOptionFallbackPool("shared"))
+
+	_, err := cr.Hash([]byte("t1/key"))
+	if err == nil {
+		t.Errorf("want err, got nil")
+	}
+
+	if err := cr.AddPool("shared", []*Node{nodeA, nodeB}, naming); err != nil {
+		t.Errorf("want nil, got: %v", err)
+	}
+	if err := cr.AddPool("t1", []*Node{nodeC}, naming); err != nil {
+		t.Errorf("want nil, got: %v", err)
+	}
+	if err := cr.AddPool("vip", []*Node{nodeD}, naming); err != nil {
+		t.Errorf("want nil, got: %v", err)
+	}
+	if err := cr.AddPool("vip", []*Node{nodeD}, naming); err == nil {
+		t.Errorf("want err, got nil")
+	}
+
+	for _, cas := range []struct {
+		name     string
+		data     string
+		wantNode *Node
+	}{
+		{name: "pool named as tenant", data: "t1/key", wantNode: nodeC},
+		{name: "pool named as tenant", data: "vip/key", wantNode: nodeD},
+		{name: "unknown tenant", data: "t2/key", wantNode: nil},
+		{name: "no tenant", data: "key", wantNode: nil},
+	} {
+		got, err := cr.Hash([]byte(cas.data))
+		if err != nil {
+			t.Errorf("%s, want nil, got: %v", cas.name, err)
+		}
+		if cas.wantNode == nil {
+			if got != nodeA && got != nodeB {
+				t.Errorf("%s, want shared pool node, got: %v", cas.name, got)
+			}
+			continue
+		}
+		if !reflect.DeepEqual(got, cas.wantNode) {
+			t.Errorf("%s, want: %v, got: %v", cas.name, cas.wantNode, got)
+		}
+	}
+
+	// move tenant
+	{
+		if err := cr.MoveTenant("t2", "vip"); err != nil {
+			t.Errorf("want nil, got: %v", err)
+		}
+		if err := cr.MoveTenant("t2", "none"); err == nil {
+			t.Errorf("want err, got nil")
+		}
+
+		got, err := cr.Hash([]byte("t2/key"))
+		if err != nil || got != nodeD {
+			t.Errorf("want: %v, got: %v, %v", nodeD, got, err)
+		}
+		pool, ok := cr.TenantPool("t2")
+		if !ok || pool != "vip" {
+			t.Errorf("want: vip, got: %v", pool)
+		}
+
+		cr.ResetTenant("t2")
+		pool, _ = cr.TenantPool("t2")
+		if pool != "shared" {
+			t.Errorf("want: shared, got: %v", pool)
+		}
+
+		got, err = cr.HashTenant("t1", []byte("anything"))
+		if err != nil || got != nodeC {
+			t.Errorf("want: %v, got: %v, %v", nodeC, got, err)
+		}
+	}
+
+	// stats
+	{
+		cr.MoveTenant("t3", "t1")
+		got := cr.Stats()
+		if got.Nodes != 4 || len(got.Pools) != 3 {
+			t.Errorf("want 4 nodes in 3 pools, got: %+v", got)
+		}
+		if got.Pools["shared"].Nodes != 2 {
+			t.Errorf("want: 2, got: %v", got.Pools["shared"].Nodes)
+		}
+		if !reflect.DeepEqual(got.Tenants, map[string]string{"t3": "t1"}) {
+			t.Errorf("want: %v, got: %v", map[string]string{"t3": "t1"}, got.Tenants)
+		}
+		sum := 0
+		for _, s := range got.Pools {
+			sum += s.VirtualNodes
+		}
+		if sum != got.VirtualNodes {
+			t.Errorf("want: %v, got: %v", sum, got.VirtualNodes)
+		}
+	}
+
+	// remove pool
+	{
+		if err := cr.RemovePool("t1"); err != nil {
+			t.Errorf("want nil, got: %v", err)
+		}
+		if err := cr.RemovePool("t1"); err == nil {
+			t.Errorf("want err, got nil")
+		}
+		pool, _ := cr.TenantPool("t3")
+		if pool != "shared" {
+			t.Errorf("want: shared, got: %v", pool)
+		}
+	}
+}
+
+func TestCHashRouterTenantResolver(t *testing.T) {
+	naming := CHashOptionNodeNaming[*Node](func(node *Node) (string, error) { return node.Name, nil })
+
+	{
+		cr := NewCHashRouter[*Node](CHashRouterOptionKeyPrefix(":"))
+		cr.AddPool("t1", []*Node{nodeA}, naming)
+
+		got, err := cr.Hash([]byte("t1:key"))
+		if err != nil || got != nodeA {
+			t.Errorf("want: %v, got: %v, %v", nodeA, got, err)
+		}
+		if _, err := cr.Hash([]byte("t1/key")); err == nil {
+			t.Errorf("want err, got nil")
+		}
+	}
+
+	{
+		cr := NewCHashRouter[*Node](CHashRouterOptionTenantResolver(func(data []byte) (string, bool) {
+			fields := strings.Fields(string(data))
+			if len(fields) < 2 {
+				return "", false
+			}
+			return fields[1], true
+		}))
+		cr.AddPool("t1", []*Node{nodeB}, naming)
+
+		got, err := cr.Hash([]byte("key t1"))
+		if err != nil || got != nodeB {
+			t.Errorf("want: %v, got: %v, %v", nodeB, got, err)
+		}
+	}
+}
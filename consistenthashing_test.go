@@ -2,6 +2,7 @@ package chper
 
 import (
 	"crypto/rand"
+	"fmt"
 	"hash/crc32"
 	mrand "math/rand"
 	"reflect"
@@ -455,3 +456,204 @@ func TestCHashWeightBalance(t *testing.T) {
 		}
 	}
 }
+
+func TestCHashHashN(t *testing.T) {
+	ch, err := NewCHash[*Node]([]*Node{nodeA, nodeB, nodeC},
+		CHashOptionNodeNaming[*Node](func(node *Node) (string, error) { return node.Name, nil }),
+	)
+	if err != nil {
+		t.Errorf("want nil, got: %v", err)
+		return
+	}
+
+	for i := 0; i < 100; i++ {
+		data := []byte(fmt.Sprint(i))
+		primary, _ := ch.Hash(data)
+
+		got, err := ch.HashN(data, 5)
+		if err != nil {
+			t.Errorf("want nil, got: %v", err)
+		}
+		if len(got) != 3 {
+			t.Errorf("want: 3, got: %v", len(got))
+		}
+		if got[0] != primary {
+			t.Errorf("want: %v, got: %v", primary, got[0])
+		}
+		if len(SliceUnique(got)) != len(got) {
+			t.Errorf("want distinct nodes, got: %v", got)
+		}
+
+		got, _ = ch.HashN(data, 2)
+		if len(got) != 2 || got[0] != primary {
+			t.Errorf("want 2 nodes begin with %v, got: %v", primary, got)
+		}
+	}
+
+	ch.RemoveNode(nodeA)
+	ch.RemoveNode(nodeB)
+	ch.RemoveNode(nodeC)
+	if _, err := ch.HashN([]byte("1"), 2); err == nil {
+		t.Errorf("want err, got nil")
+	}
+}
+
+func chashPeakToMean(ch *CHash[string], nodeCount, total int) float64 {
+	frequencies := map[string]int{}
+	for i := 0; i < total; i++ {
+		node, _ := ch.Hash([]byte(fmt.Sprintf("key-%d", i)))
+		frequencies[node]++
+	}
+
+	peak := 0
+	for _, count := range frequencies {
+		if count > peak {
+			peak = count
+		}
+	}
+
+	return float64(peak) * float64(nodeCount) / float64(total)
+}
+
+func TestCHashMultiProbe(t *testing.T) {
+	naming := CHashOptionNodeNaming[string](func(node string) (string, error) { return node, nil })
+	nodes := []string{}
+	for i := 0; i < 10; i++ {
+		nodes = append(nodes, fmt.Sprintf("node-%d", i))
+	}
+
+	vnode, err := NewCHash(nodes, naming)
+	if err != nil {
+		t.Errorf("want nil, got: %v", err)
+		return
+	}
+	multiProbe, err := NewCHash(nodes, naming, CHashOptionMultiProbe[string](21))
+	if err != nil {
+		t.Errorf("want nil, got: %v", err)
+		return
+	}
+
+	if got := len(multiProbe.virtualNodeList); got != len(nodes) {
+		t.Errorf("want: %d, got: %d", len(nodes), got)
+	}
+
+	total := 100000
+	vnodeRatio := chashPeakToMean(vnode, len(nodes), total)
+	multiProbeRatio := chashPeakToMean(multiProbe, len(nodes), total)
+	t.Logf("peak-to-mean, vnode(%d points): %.3f, multi-probe(%d points): %.3f",
+		len(vnode.virtualNodeList), vnodeRatio, len(multiProbe.virtualNodeList), multiProbeRatio)
+	if multiProbeRatio > 1.15 {
+		t.Errorf("want peak-to-mean <= 1.15, got: %.3f", multiProbeRatio)
+	}
+
+	// consistency: keys only move to the added node
+	before := map[string]string{}
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprint(i)
+		before[key], _ = multiProbe.Hash([]byte(key))
+	}
+	if err := multiProbe.AddNode("node-new"); err != nil {
+		t.Errorf("want nil, got: %v", err)
+	}
+	for key, node := range before {
+		got, _ := multiProbe.Hash([]byte(key))
+		if got != node && got != "node-new" {
+			t.Errorf("key: %s, want: %s or node-new, got: %s", key, node, got)
+		}
+	}
+
+	replicas, _ := multiProbe.HashN([]byte("1"), 3)
+	primary, _ := multiProbe.Hash([]byte("1"))
+	if len(replicas) != 3 || replicas[0] != primary {
+		t.Errorf("want 3 nodes begin with %v, got: %v", primary, replicas)
+	}
+}
+
+func benchmarkCHash(b *testing.B, options ...chashOptionFunc[string]) {
+	nodes := []string{}
+	for i := 0; i < 10; i++ {
+		nodes = append(nodes, fmt.Sprintf("node-%d", i))
+	}
+	options = append(options, CHashOptionNodeNaming[string](func(node string) (string, error) { return node, nil }))
+
+	b.Run("new", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			NewCHash(nodes, options...)
+		}
+	})
+
+	ch, _ := NewCHash(nodes, options...)
+	data := []byte("benchmark-key")
+	b.Run("hash", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			ch.Hash(data)
+		}
+	})
+}
+
+func BenchmarkCHashVirtualNode(b *testing.B) {
+	benchmarkCHash(b)
+}
+
+func BenchmarkCHashMultiProbe(b *testing.B) {
+	benchmarkCHash(b, CHashOptionMultiProbe[string](21))
+}
+
+func TestCHashMultiProbeNoAlloc(t *testing.T) {
+	ch, err := NewCHash([]string{"a", "b", "c"}, CHashOptionMultiProbe[string](21))
+	if err != nil {
+		t.Errorf("want nil, got: %v", err)
+		return
+	}
+
+	data := []byte("key")
+	if got := testing.AllocsPerRun(100, func() { ch.Hash(data) }); got != 0 {
+		t.Errorf("want: 0, got: %v", got)
+	}
+}
+
+func TestCHashApplyChanges(t *testing.T) {
+	ch, err := NewCHash[*Node]([]*Node{nodeA, nodeB},
+		CHashOptionNodeNaming[*Node](func(node *Node) (string, error) { return node.Name, nil }),
+	)
+	if err != nil {
+		t.Errorf("want nil, got: %v", err)
+		return
+	}
+	stats := ch.Stats()
+
+	for _, cas := range []struct {
+		name    string
+		adds    []*Node
+		removes []*Node
+	}{
+		{name: "add existed", adds: []*Node{nodeC, nodeA}},
+		{name: "add twice", adds: []*Node{nodeC, nodeC}},
+		{name: "remove not exist", adds: []*Node{nodeC}, removes: []*Node{nodeD}},
+	} {
+		if err := ch.ApplyChanges(cas.adds, cas.removes); err == nil {
+			t.Errorf("%s, want err, got nil", cas.name)
+		}
+		if got := ch.Stats(); got != stats {
+			t.Errorf("%s, want: %v, got: %v", cas.name, stats, got)
+		}
+	}
+
+	if err := ch.ApplyChanges([]*Node{nodeC, nodeA}, []*Node{nodeA, nodeB}); err != nil {
+		t.Errorf("want nil, got: %v", err)
+	}
+	names := MapKeys(ch.Nodes())
+	SliceSort(names)
+	if want := []string{"A", "C"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("want: %v, got: %v", want, names)
+	}
+	if got := ch.Stats(); got != stats {
+		t.Errorf("want: %v, got: %v", stats, got)
+	}
+	for i := 0; i < 100; i++ {
+		node, _ := ch.Hash([]byte(fmt.Sprint(i)))
+		if node != nodeA && node != nodeC {
+			t.Errorf("want A or C, got: %v", node)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package chper
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// Discoverer return the current node set
+type Discoverer[Node any] interface {
+	Discover(ctx context.Context) ([]Node, error)
+}
+
+// DiscovererFunc is an adapter to use ordinary function as Discoverer
+type DiscovererFunc[Node any] func(ctx context.Context) ([]Node, error)
+
+func (f DiscovererFunc[Node]) Discover(ctx context.Context) ([]Node, error) {
+	return f(ctx)
+}
+
+// Reconciler keeps CHash membership in sync with Discoverer
+type Reconciler[Node any] struct {
+	ch         *CHash[Node]
+	discoverer Discoverer[Node]
+}
+
+func NewReconciler[Node any](ch *CHash[Node], discoverer Discoverer[Node]) *Reconciler[Node] {
+	return &Reconciler[Node]{
+		ch:         ch,
+		discoverer: discoverer,
+	}
+}
+
+// ReconcileResult include node names which are changed
+type ReconcileResult struct {
+	Added   []string
+	Removed []string
+	// Updated nodes have the same name but diffrent value, they are replaced
+	Updated []string
+}
+
+// Reconcile discover nodes, diff them against CHash membership by MapCompare,
+// and apply adds and removes in one batch
+// an empty node set is treated as an error, CHash will not be cleared
+func (r *Reconciler[Node]) Reconcile(ctx context.Context) (result ReconcileResult, err error) {
+	nodes, err := r.discoverer.Discover(ctx)
+	if err != nil {
+		err = fmt.Errorf("discover fail, err : %w", err)
+		return
+	}
+	if len(nodes) == 0 {
+		err = ErrNoNode
+		return
+	}
+
+	discovered := make(map[string]Node, len(nodes))
+	for _, node := range nodes {
+		name, err := r.ch.option.nodeNaming(node)
+		if err != nil {
+			return result, &NodeNamingError{Err: err}
+		}
+		discovered[name] = node
+	}
+
+	current := r.ch.Nodes()
+	justCurrent, justDiscovered, updated := MapCompare(current, discovered)
+
+	var adds, removes []Node
+	for name := range justCurrent {
+		removes = append(removes, current[name])
+		result.Removed = append(result.Removed, name)
+	}
+	for name := range justDiscovered {
+		adds = append(adds, discovered[name])
+		result.Added = append(result.Added, name)
+	}
+	for name := range updated {
+		removes = append(removes, current[name])
+		adds = append(adds, discovered[name])
+		result.Updated = append(result.Updated, name)
+	}
+	SliceSort(result.Added)
+	SliceSort(result.Removed)
+	SliceSort(result.Updated)
+
+	if len(adds) == 0 && len(removes) == 0 {
+		return
+	}
+
+	err = r.ch.ApplyChangesContext(ctx, adds, removes)
+	if err != nil {
+		return ReconcileResult{}, err
+	}
+
+	return
+}
+
+// Run call Reconcile every interval until ctx is done
+// onResult is optional, it is called after every Reconcile
+func (r *Reconciler[Node]) Run(ctx context.Context, interval time.Duration,
+	onResult func(ReconcileResult, error)) error {
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		result, err := r.Reconcile(ctx)
+		if onResult != nil {
+			onResult(result, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// FileDiscoverer read nodes from a JSON file, the file content is a JSON array of Node
+// the file is reloaded only if its size or modify time changed
+type FileDiscoverer[Node any] struct {
+	path string
+
+	modTime time.Time
+	size    int64
+	nodes   []Node
+
+	lock sync.Mutex
+}
+
+func NewFileDiscoverer[Node any](path string) *FileDiscoverer[Node] {
+	return &FileDiscoverer[Node]{path: path}
+}
+
+func (fd *FileDiscoverer[Node]) Discover(ctx context.Context) ([]Node, error) {
+	fd.lock.Lock()
+	defer fd.lock.Unlock()
+
+	info, err := os.Stat(fd.path)
+	if err != nil {
+		return nil, err
+	}
+	if fd.nodes != nil && info.ModTime().Equal(fd.modTime) && info.Size() == fd.size {
+		return fd.nodes, nil
+	}
+
+	bs, err := os.ReadFile(fd.path)
+	if err != nil {
+		return nil, err
+	}
+	nodes := []Node{}
+	if err := json.Unmarshal(bs, &nodes); err != nil {
+		return nil, fmt.Errorf("unmarshal %s fail, err : %w", fd.path, err)
+	}
+
+	fd.nodes, fd.modTime, fd.size = nodes, info.ModTime(), info.Size()
+	return nodes, nil
+}
+
+// SRVResolver lookup DNS SRV records, *net.Resolver implements it
+type SRVResolver interface {
+	LookupSRV(ctx context.Context, service, proto, name string) (cname string, addrs []*net.SRV, err error)
+}
+
+// DNSDiscoverer discover nodes by DNS SRV records
+type DNSDiscoverer[Node any] struct {
+	service, proto, name string
+
+	convertor func(srv *net.SRV) (Node, error)
+	resolver  SRVResolver
+}
+
+// NewDNSDiscoverer create a DNSDiscoverer, convertor convert SRV record to Node
+// resolver is optional, default is net.DefaultResolver
+func NewDNSDiscoverer[Node any](service, proto, name string,
+	convertor func(srv *net.SRV) (Node, error), resolverOpt ...SRVResolver) *DNSDiscoverer[Node] {
+
+	var resolver SRVResolver = net.DefaultResolver
+	if len(resolverOpt) == 1 {
+		resolver = resolverOpt[0]
+	}
+
+	return &DNSDiscoverer[Node]{
+		service: service,
+		proto:   proto,
+		name:    name,
+
+		convertor: convertor,
+		resolver:  resolver,
+	}
+}
+
+func (dd *DNSDiscoverer[Node]) Discover(ctx context.Context) ([]Node, error) {
+	_, srvs, err := dd.resolver.LookupSRV(ctx, dd.service, dd.proto, dd.name)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make([]Node, 0, len(srvs))
+	for _, srv := range srvs {
+		node, err := dd.convertor(srv)
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+	}
+
+	return nodes, nil
+}
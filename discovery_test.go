@@ -0,0 +1,235 @@
+package chper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type fakeSRVResolver struct {
+	records map[string][]*net.SRV
+	err     error
+}
+
+func (fr *fakeSRVResolver) LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error) {
+	if fr.err != nil {
+		return "", nil, fr.err
+	}
+
+	cname := fmt.Sprintf("_%s._%s.%s", service, proto, name)
+	return cname, fr.records[cname], nil
+}
+
+func chashNodeNames[Node any](ch *CHash[Node]) []string {
+	names := MapKeys(ch.Nodes())
+	SliceSort(names)
+	return names
+}
+
+func TestReconciler(t *testing.T) {
+	ch, err := NewCHash([]*Node{nodeA, nodeB},
+		CHashOptionNodeNaming[*Node](func(node *Node) (string, error) { return node.Name, nil }),
+	)
+	if err != nil {
+		t.Errorf("want nil, got: %v", err)
+		return
+	}
+
+	discovered := []*Node{nodeB, nodeC, nodeD}
+	var discoverErr error
+	r := NewReconciler[*Node](ch, DiscovererFunc[*Node](func(ctx context.Context) ([]*Node, error) {
+		return discovered, discoverErr
+	}))
+
+	got, err := r.Reconcile(context.Background())
+	if err != nil {
+		t.Errorf("want nil, got: %v", err)
+	}
+	want := ReconcileResult{Added: []string{"C", "D"}, Removed: []string{"A"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want: %+v, got: %+v", want, got)
+	}
+	if names := chashNodeNames(ch); !reflect.DeepEqual(names, []string{"B", "C", "D"}) {
+		t.Errorf("want: %v, got: %v", []string{"B", "C", "D"}, names)
+	}
+
+	// nothing changed
+	got, err = r.Reconcile(context.Background())
+	if err != nil || !reflect.DeepEqual(got, ReconcileResult{}) {
+		t.Errorf("want empty result, got: %+v, %v", got, err)
+	}
+
+	// empty node set and discover error keep membership
+	discovered = nil
+	if _, err := r.Reconcile(context.Background()); !errors.Is(err, ErrNoNode) {
+		t.Errorf("want: %v, got: %v", ErrNoNode, err)
+	}
+	discoverErr = fmt.Errorf("timeout")
+	if _, err := r.Reconcile(context.Background()); err == nil {
+		t.Errorf("want err, got nil")
+	}
+	if names := chashNodeNames(ch); !reflect.DeepEqual(names, []string{"B", "C", "D"}) {
+		t.Errorf("want: %v, got: %v", []string{"B", "C", "D"}, names)
+	}
+}
+
+func TestReconcilerUpdated(t *testing.T) {
+	type addr struct {
+		Name string
+		Port int
+	}
+	ch, err := NewCHash([]addr{{"a", 1}, {"b", 1}},
+		CHashOptionNodeNaming[addr](func(node addr) (string, error) { return node.Name, nil }),
+	)
+	if err != nil {
+		t.Errorf("want nil, got: %v", err)
+		return
+	}
+
+	r := NewReconciler[addr](ch, DiscovererFunc[addr](func(ctx context.Context) ([]addr, error) {
+		return []addr{{"a", 1}, {"b", 2}}, nil
+	}))
+	got, err := r.Reconcile(context.Background())
+	if err != nil {
+		t.Errorf("want nil, got: %v", err)
+	}
+	if want := (ReconcileResult{Updated: []string{"b"}}); !reflect.DeepEqual(got, want) {
+		t.Errorf("want: %+v, got: %+v", want, got)
+	}
+	if got := ch.Nodes()["b"]; got.Port != 2 {
+		t.Errorf("want: 2, got: %v", got.Port)
+	}
+}
+
+func TestReconcilerRun(t *testing.T) {
+	ch, _ := NewCHash([]*Node{nodeA},
+		CHashOptionNodeNaming[*Node](func(node *Node) (string, error) { return node.Name, nil }),
+	)
+	r := NewReconciler[*Node](ch, DiscovererFunc[*Node](func(ctx context.Context) ([]*Node, error) {
+		return []*Node{nodeB}, nil
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	results := []ReconcileResult{}
+	err := r.Run(ctx, time.Millisecond, func(result ReconcileResult, err error) {
+		results = append(results, result)
+		if len(results) == 3 {
+			cancel()
+		}
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("want: %v, got: %v", context.Canceled, err)
+	}
+	if len(results) != 3 || len(results[0].Added) != 1 || len(results[1].Added) != 0 {
+		t.Errorf("want 3 results, only the first one changed, got: %+v", results)
+	}
+}
+
+func TestFileDiscoverer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nodes.json")
+	fd := NewFileDiscoverer[*Node](path)
+
+	if _, err := fd.Discover(context.Background()); err == nil {
+		t.Errorf("want err, got nil")
+	}
+
+	write := func(content string, modTime time.Time) {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	now := time.Now()
+
+	write(`[{"Name":"A"},{"Name":"B"}]`, now)
+	got, err := fd.Discover(context.Background())
+	if err != nil {
+		t.Errorf("want nil, got: %v", err)
+	}
+	if want := []*Node{nodeA, nodeB}; !reflect.DeepEqual(got, want) {
+		t.Errorf("want: %v, got: %v", want, got)
+	}
+
+	// same size and modify time, not reloaded
+	write(`[{"Name":"C"},{"Name":"D"}]`, now)
+	got, _ = fd.Discover(context.Background())
+	if want := []*Node{nodeA, nodeB}; !reflect.DeepEqual(got, want) {
+		t.Errorf("want: %v, got: %v", want, got)
+	}
+
+	write(`[{"Name":"C"},{"Name":"D"}]`, now.Add(time.Second))
+	got, _ = fd.Discover(context.Background())
+	if want := []*Node{nodeC, nodeD}; !reflect.DeepEqual(got, want) {
+		t.Errorf("want: %v, got: %v", want, got)
+	}
+
+	write(`[{"Name":`, now.Add(2*time.Second))
+	if _, err := fd.Discover(context.Background()); err == nil {
+		t.Errorf("want err, got nil")
+	}
+
+	// work with Reconciler
+	write(`[{"Name":"B"},{"Name":"C"}]`, now.Add(3*time.Second))
+	ch, _ := NewCHash([]*Node{nodeA},
+		CHashOptionNodeNaming[*Node](func(node *Node) (string, error) { return node.Name, nil }),
+	)
+	if _, err := NewReconciler[*Node](ch, fd).Reconcile(context.Background()); err != nil {
+		t.Errorf("want nil, got: %v", err)
+	}
+	if names := chashNodeNames(ch); !reflect.DeepEqual(names, []string{"B", "C"}) {
+		t.Errorf("want: %v, got: %v", []string{"B", "C"}, names)
+	}
+}
+
+func TestDNSDiscoverer(t *testing.T) {
+	resolver := &fakeSRVResolver{records: map[string][]*net.SRV{
+		"_cache._tcp.example.com": {
+			{Target: "n1.example.com.", Port: 6379},
+			{Target: "n2.example.com.", Port: 6380},
+		},
+	}}
+	convertor := func(srv *net.SRV) (string, error) {
+		return net.JoinHostPort(srv.Target, fmt.Sprint(srv.Port)), nil
+	}
+	dd := NewDNSDiscoverer("cache", "tcp", "example.com", convertor, resolver)
+
+	got, err := dd.Discover(context.Background())
+	if err != nil {
+		t.Errorf("want nil, got: %v", err)
+	}
+	want := []string{"n1.example.com.:6379", "n2.example.com.:6380"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want: %v, got: %v", want, got)
+	}
+
+	ch, _ := NewCHash([]string{"n1.example.com.:6379", "old:1"},
+		CHashOptionNodeNaming[string](func(node string) (string, error) { return node, nil }),
+	)
+	result, err := NewReconciler[string](ch, dd).Reconcile(context.Background())
+	if err != nil {
+		t.Errorf("want nil, got: %v", err)
+	}
+	if want := (ReconcileResult{Added: []string{"n2.example.com.:6380"}, Removed: []string{"old:1"}}); !reflect.DeepEqual(result, want) {
+		t.Errorf("want: %+v, got: %+v", want, result)
+	}
+
+	resolver.err = fmt.Errorf("no such host")
+	if _, err := dd.Discover(context.Background()); err == nil {
+		t.Errorf("want err, got nil")
+	}
+
+	dd = NewDNSDiscoverer("cache", "tcp", "example.com", func(srv *net.SRV) (string, error) {
+		return "", fmt.Errorf("bad record")
+	}, &fakeSRVResolver{records: resolver.records})
+	if _, err := dd.Discover(context.Background()); err == nil {
+		t.Errorf("want err, got nil")
+	}
+}
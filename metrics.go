@@ -0,0 +1,204 @@
+package chper
+
+import (
+	"bufio"
+	"expvar"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// RingMetricsSource is the part of Ring which Metrics reads
+type RingMetricsSource interface {
+	Size() int
+	Capacity() int
+	Stats() RingStats
+}
+
+// CHashMetricsSource is the part of CHash which Metrics reads
+type CHashMetricsSource interface {
+	Stats() CHashStats
+	Version() uint64
+	Lookups() uint64
+}
+
+type metricDesc struct {
+	// key is the name in expvar
+	key  string
+	name string
+	help string
+	typ  string
+}
+
+var (
+	metricRingSize      = metricDesc{"size", "chper_ring_size", "Elements count of the ring.", "gauge"}
+	metricRingCapacity  = metricDesc{"capacity", "chper_ring_capacity", "Capacity of the ring.", "gauge"}
+	metricRingPushed    = metricDesc{"pushed", "chper_ring_pushed_total", "Total count of pushed elements.", "counter"}
+	metricRingEvictions = metricDesc{"evictions", "chper_ring_evictions_total", "Total count of evicted elements.", "counter"}
+
+	metricCHashNodes        = metricDesc{"nodes", "chper_chash_nodes", "Real nodes count of the consistent hashing.", "gauge"}
+	metricCHashVirtualNodes = metricDesc{"virtual_nodes", "chper_chash_virtual_nodes", "Virtual nodes count of the consistent hashing.", "gauge"}
+	metricCHashVersion      = metricDesc{"version", "chper_chash_version", "Membership version of the consistent hashing.", "gauge"}
+	metricCHashLookups      = metricDesc{"lookups", "chper_chash_lookups_total", "Total count of lookups.", "counter"}
+
+	// metricDescs is the output order of Prometheus text
+	metricDescs = []metricDesc{
+		metricRingSize, metricRingCapacity, metricRingPushed, metricRingEvictions,
+		metricCHashNodes, metricCHashVirtualNodes, metricCHashVersion, metricCHashLookups,
+	}
+)
+
+type metricValue struct {
+	desc  metricDesc
+	value float64
+}
+
+type metricsTarget struct {
+	// kind is "ring" or "chash", it is also the label name in Prometheus text
+	kind    string
+	name    string
+	collect func() []metricValue
+}
+
+// Metrics publish metrics of registered Rings and CHashes
+// it works through expvar by Publish, and it is an http.Handler serving the Prometheus text format
+type Metrics struct {
+	targets map[string]metricsTarget
+
+	lock sync.RWMutex
+}
+
+func NewMetrics() *Metrics {
+	return &Metrics{targets: map[string]metricsTarget{}}
+}
+
+// RegisterRing register r with name, a registered ring with the same name is replaced
+func (m *Metrics) RegisterRing(name string, r RingMetricsSource) {
+	m.register(metricsTarget{kind: "ring", name: name, collect: func() []metricValue {
+		stats := r.Stats()
+		return []metricValue{
+			{metricRingSize, float64(r.Size())},
+			{metricRingCapacity, float64(r.Capacity())},
+			{metricRingPushed, float64(stats.Pushed)},
+			{metricRingEvictions, float64(stats.Evicted)},
+		}
+	}})
+}
+
+// RegisterCHash register ch with name, a registered CHash with the same name is replaced
+func (m *Metrics) RegisterCHash(name string, ch CHashMetricsSource) {
+	m.register(metricsTarget{kind: "chash", name: name, collect: func() []metricValue {
+		stats := ch.Stats()
+		return []metricValue{
+			{metricCHashNodes, float64(stats.Nodes)},
+			{metricCHashVirtualNodes, float64(stats.VirtualNodes)},
+			{metricCHashVersion, float64(ch.Version())},
+			{metricCHashLookups, float64(ch.Lookups())},
+		}
+	}})
+}
+
+func (m *Metrics) register(target metricsTarget) {
+	m.lock.Lock()
+	m.targets[target.kind+"/"+target.name] = target
+	m.lock.Unlock()
+}
+
+// UnregisterRing remove the ring registered with name
+func (m *Metrics) UnregisterRing(name string) {
+	m.lock.Lock()
+	delete(m.targets, "ring/"+name)
+	m.lock.Unlock()
+}
+
+// UnregisterCHash remove the CHash registered with name
+func (m *Metrics) UnregisterCHash(name string) {
+	m.lock.Lock()
+	delete(m.targets, "chash/"+name)
+	m.lock.Unlock()
+}
+
+// sortedTargets return targets sorted by kind and name
+func (m *Metrics) sortedTargets() []metricsTarget {
+	m.lock.RLock()
+	targets := MapValues(m.targets)
+	m.lock.RUnlock()
+
+	sort.Slice(targets, func(i, j int) bool {
+		if targets[i].kind != targets[j].kind {
+			return targets[i].kind > targets[j].kind // ring first
+		}
+		return targets[i].name < targets[j].name
+	})
+
+	return targets
+}
+
+// Publish publish the metrics to expvar with name, it panics if name is already published like expvar.Publish
+// the value is {"ring": {name: {"size": 1, ...}}, "chash": {name: {"nodes": 3, ...}}}
+func (m *Metrics) Publish(name string) {
+	expvar.Publish(name, expvar.Func(func() any {
+		return m.expvarValue()
+	}))
+}
+
+func (m *Metrics) expvarValue() map[string]map[string]map[string]float64 {
+	value := map[string]map[string]map[string]float64{}
+	for _, target := range m.sortedTargets() {
+		if value[target.kind] == nil {
+			value[target.kind] = map[string]map[string]float64{}
+		}
+
+		values := map[string]float64{}
+		for _, v := range target.collect() {
+			values[v.desc.key] = v.value
+		}
+		value[target.kind][target.name] = values
+	}
+
+	return value
+}
+
+// WritePrometheus write the metrics in the Prometheus text exposition format
+func (m *Metrics) WritePrometheus(w io.Writer) error {
+	values := map[string][]string{}
+	for _, target := range m.sortedTargets() {
+		for _, v := range target.collect() {
+			line := fmt.Sprintf("%s{%s=\"%s\"} %s\n", v.desc.name, target.kind, escapeLabelValue(target.name),
+				strconv.FormatFloat(v.value, 'g', -1, 64))
+			values[v.desc.name] = append(values[v.desc.name], line)
+		}
+	}
+
+	bw := bufio.NewWriter(w)
+	for _, desc := range metricDescs {
+		lines := values[desc.name]
+		if len(lines) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(bw, "# HELP %s %s\n", desc.name, desc.help)
+		fmt.Fprintf(bw, "# TYPE %s %s\n", desc.name, desc.typ)
+		for _, line := range lines {
+			bw.WriteString(line)
+		}
+	}
+
+	return bw.Flush()
+}
+
+var labelValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabelValue(s string) string {
+	return labelValueReplacer.Replace(s)
+}
+
+// ServeHTTP implements http.Handler, it writes the metrics in the Prometheus text exposition format
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.WritePrometheus(w)
+}
@@ -0,0 +1,162 @@
+package chper
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+)
+
+func newMetricsTestCHash(t *testing.T) *CHash[*Node] {
+	t.Helper()
+
+	ch, err := NewCHash[*Node]([]*Node{nodeA, nodeB},
+		CHashOptionNodeNaming[*Node](func(node *Node) (string, error) { return node.Name, nil }),
+		CHashOptionVirtualNodeFactor[*Node](10),
+		CHashOptionCountLookups[*Node](true),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return ch
+}
+
+func TestCHashVersionLookups(t *testing.T) {
+	ch := newMetricsTestCHash(t)
+
+	version := ch.Version()
+	if err := ch.AddNode(nodeC); err != nil {
+		t.Fatal(err)
+	}
+	if got := ch.Version(); got != version+1 {
+		t.Errorf("want: %d, got: %d", version+1, got)
+	}
+	ch.AddNode(nodeC) // fail
+	if got := ch.Version(); got != version+1 {
+		t.Errorf("want: %d, got: %d", version+1, got)
+	}
+	ch.ApplyChanges([]*Node{nodeD}, []*Node{nodeA})
+	if got := ch.Version(); got != version+2 {
+		t.Errorf("want: %d, got: %d", version+2, got)
+	}
+
+	ch.Hash([]byte("a"))
+	ch.Hash([]byte("b"))
+	ch.HashN([]byte("c"), 2)
+	if got := ch.Lookups(); got != 3 {
+		t.Errorf("want: 3, got: %d", got)
+	}
+
+	// lookups are not counted by default
+	ch, _ = NewCHash[*Node]([]*Node{nodeA, nodeB},
+		CHashOptionNodeNaming[*Node](func(node *Node) (string, error) { return node.Name, nil }),
+	)
+	ch.Hash([]byte("a"))
+	ch.HashN([]byte("b"), 2)
+	if got := ch.Lookups(); got != 0 {
+		t.Errorf("want: 0, got: %d", got)
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	r := NewRing[int](2)
+	for i := 0; i < 3; i++ {
+		r.Push(i)
+	}
+	ch := newMetricsTestCHash(t)
+	ch.Hash([]byte("a"))
+
+	m := NewMetrics()
+	m.RegisterRing("recent", r)
+	m.RegisterRing(`a"b`, NewRing[int](1))
+	m.RegisterCHash("cache", ch)
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if got := rec.Header().Get("Content-Type"); got != "text/plain; version=0.0.4; charset=utf-8" {
+		t.Errorf("want prometheus content type, got: %v", got)
+	}
+
+	want := `# HELP chper_ring_size Elements count of the ring.
+# TYPE chper_ring_size gauge
+chper_ring_size{ring="a\"b"} 0
+chper_ring_size{ring="recent"} 2
+# HELP chper_ring_capacity Capacity of the ring.
+# TYPE chper_ring_capacity gauge
+chper_ring_capacity{ring="a\"b"} 1
+chper_ring_capacity{ring="recent"} 2
+# HELP chper_ring_pushed_total Total count of pushed elements.
+# TYPE chper_ring_pushed_total counter
+chper_ring_pushed_total{ring="a\"b"} 0
+chper_ring_pushed_total{ring="recent"} 3
+# HELP chper_ring_evictions_total Total count of evicted elements.
+# TYPE chper_ring_evictions_total counter
+chper_ring_evictions_total{ring="a\"b"} 0
+chper_ring_evictions_total{ring="recent"} 1
+# HELP chper_chash_nodes Real nodes count of the consistent hashing.
+# TYPE chper_chash_nodes gauge
+chper_chash_nodes{chash="cache"} 2
+# HELP chper_chash_virtual_nodes Virtual nodes count of the consistent hashing.
+# TYPE chper_chash_virtual_nodes gauge
+chper_chash_virtual_nodes{chash="cache"} 20
+# HELP chper_chash_version Membership version of the consistent hashing.
+# TYPE chper_chash_version gauge
+chper_chash_version{chash="cache"} 1
+# HELP chper_chash_lookups_total Total count of lookups.
+# TYPE chper_chash_lookups_total counter
+chper_chash_lookups_total{chash="cache"} 1
+`
+	if got := rec.Body.String(); got != want {
+		t.Errorf("want: %s, got: %s", want, got)
+	}
+
+	m.UnregisterRing(`a"b`)
+	m.UnregisterCHash("cache")
+	rec = httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if want := `# HELP chper_ring_size Elements count of the ring.
+# TYPE chper_ring_size gauge
+chper_ring_size{ring="recent"} 2
+# HELP chper_ring_capacity Capacity of the ring.
+# TYPE chper_ring_capacity gauge
+chper_ring_capacity{ring="recent"} 2
+# HELP chper_ring_pushed_total Total count of pushed elements.
+# TYPE chper_ring_pushed_total counter
+chper_ring_pushed_total{ring="recent"} 3
+# HELP chper_ring_evictions_total Total count of evicted elements.
+# TYPE chper_ring_evictions_total counter
+chper_ring_evictions_total{ring="recent"} 1
+`; rec.Body.String() != want {
+		t.Errorf("want: %s, got: %s", want, rec.Body.String())
+	}
+}
+
+var expvarTestSeq atomic.Int64
+
+func TestMetricsExpvar(t *testing.T) {
+	r := NewRing[int](2)
+	r.Push(1)
+
+	m := NewMetrics()
+	m.RegisterRing("recent", r)
+	m.RegisterCHash("cache", newMetricsTestCHash(t))
+	// expvar names can not be reused, so every run publishes a new name, e.g. go test -count=2
+	name := fmt.Sprintf("chper_test_metrics_%d", expvarTestSeq.Add(1))
+	m.Publish(name)
+
+	got := map[string]map[string]map[string]float64{}
+	if err := json.Unmarshal([]byte(expvar.Get(name).String()), &got); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]map[string]map[string]float64{
+		"ring":  {"recent": {"size": 1, "capacity": 2, "pushed": 1, "evictions": 0}},
+		"chash": {"cache": {"nodes": 2, "virtual_nodes": 20, "version": 1, "lookups": 0}},
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("want: %v, got: %v", want, got)
+	}
+}
@@ -0,0 +1,256 @@
+package chper
+
+import (
+	"container/heap"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// ReservoirAlgorithm is the algorithm of Reservoir
+type ReservoirAlgorithm int
+
+const (
+	// ReservoirAlgorithmR is Vitter's Algorithm R, it draws one random number per element
+	ReservoirAlgorithmR ReservoirAlgorithm = iota
+	// ReservoirAlgorithmL is Li's Algorithm L, it skips elements which will not be sampled, so it is faster on long streams
+	ReservoirAlgorithmL
+)
+
+type reservoirOption struct {
+	algorithm ReservoirAlgorithm
+	random    *rand.Rand
+}
+
+func defaultReservoirOption() *reservoirOption {
+	return &reservoirOption{
+		algorithm: ReservoirAlgorithmR,
+		random:    rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+type reservoirOptionFunc func(*reservoirOption)
+
+// ReservoirOptionAlgorithm specify the algorithm, default is ReservoirAlgorithmR
+// it is ignored by WeightedReservoir
+func ReservoirOptionAlgorithm(algorithm ReservoirAlgorithm) reservoirOptionFunc {
+	return func(ro *reservoirOption) {
+		ro.algorithm = algorithm
+	}
+}
+
+// ReservoirOptionRandSource specify the random source, e.g. a fixed seed for deterministic tests
+func ReservoirOptionRandSource(source rand.Source) reservoirOptionFunc {
+	return func(ro *reservoirOption) {
+		ro.random = rand.New(source)
+	}
+}
+
+// Reservoir keeps a uniform sample of at most capacity elements over all pushed elements
+// while Ring keeps the most recent ones
+type Reservoir[V any] struct {
+	capacity int
+	elements []V
+	seen     uint64
+
+	option *reservoirOption
+
+	// w and skip are the state of Algorithm L
+	w    float64
+	skip uint64
+
+	lock sync.Mutex
+}
+
+func NewReservoir[V any](capacity int, options ...reservoirOptionFunc) *Reservoir[V] {
+	if capacity < 1 {
+		panic("bad capacity")
+	}
+
+	option := defaultReservoirOption()
+	for _, f := range options {
+		f(option)
+	}
+
+	return &Reservoir[V]{
+		capacity: capacity,
+		elements: make([]V, 0, capacity),
+		option:   option,
+	}
+}
+
+// Push offer one element to the sample
+func (r *Reservoir[V]) Push(data V) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.seen++
+	if len(r.elements) < r.capacity {
+		r.elements = append(r.elements, data)
+		if len(r.elements) == r.capacity && r.option.algorithm == ReservoirAlgorithmL {
+			r.w = math.Exp(math.Log(r.random()) / float64(r.capacity))
+			r.nextSkip()
+		}
+		return
+	}
+
+	switch r.option.algorithm {
+	case ReservoirAlgorithmL:
+		if r.skip > 0 {
+			r.skip--
+			return
+		}
+		r.elements[r.option.random.Intn(r.capacity)] = data
+		r.w *= math.Exp(math.Log(r.random()) / float64(r.capacity))
+		r.nextSkip()
+	default:
+		if j := r.option.random.Int63n(int64(r.seen)); j < int64(r.capacity) {
+			r.elements[j] = data
+		}
+	}
+}
+
+// random return a random number in (0, 1]
+func (r *Reservoir[V]) random() float64 {
+	return 1 - r.option.random.Float64()
+}
+
+// nextSkip compute how many elements are skipped before the next replacement
+func (r *Reservoir[V]) nextSkip() {
+	skip := math.Floor(math.Log(r.random()) / math.Log(1-r.w))
+	if skip > math.MaxInt64 || math.IsNaN(skip) {
+		skip = math.MaxInt64
+	}
+	r.skip = uint64(skip)
+}
+
+// Elements return matched elements of the sample, the order is not specified
+func (r *Reservoir[V]) Elements(filter func(V) bool) (elements []V) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	for _, ele := range r.elements {
+		if filter == nil || filter(ele) {
+			elements = append(elements, ele)
+		}
+	}
+
+	return
+}
+
+// Size return the sample's elements count
+func (r *Reservoir[V]) Size() int {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return len(r.elements)
+}
+
+// Seen return the count of all pushed elements
+func (r *Reservoir[V]) Seen() uint64 {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return r.seen
+}
+
+// WeightedReservoir keeps a weighted sample of at most capacity elements by Algorithm A-Res (Efraimidis and Spirakis)
+// an element is sampled with probability proportional to its weight
+type WeightedReservoir[V any] struct {
+	capacity int
+	// elements is a min heap by key, the smallest key is replaced
+	elements weightedHeap[V]
+	seen     uint64
+
+	option *reservoirOption
+
+	lock sync.Mutex
+}
+
+type weightedElement[V any] struct {
+	key  float64
+	data V
+}
+
+func NewWeightedReservoir[V any](capacity int, options ...reservoirOptionFunc) *WeightedReservoir[V] {
+	if capacity < 1 {
+		panic("bad capacity")
+	}
+
+	option := defaultReservoirOption()
+	for _, f := range options {
+		f(option)
+	}
+
+	return &WeightedReservoir[V]{
+		capacity: capacity,
+		elements: make(weightedHeap[V], 0, capacity),
+		option:   option,
+	}
+}
+
+// Push offer one element with weight, an element whose weight is not positive is never sampled
+func (wr *WeightedReservoir[V]) Push(data V, weight float64) {
+	wr.lock.Lock()
+	defer wr.lock.Unlock()
+
+	wr.seen++
+	if !(weight > 0) {
+		return
+	}
+
+	// key = u^(1/weight), computed in log space to keep precision for large weights
+	key := math.Log(1-wr.option.random.Float64()) / weight
+	if len(wr.elements) < wr.capacity {
+		heap.Push(&wr.elements, weightedElement[V]{key: key, data: data})
+		return
+	}
+	if key > wr.elements[0].key {
+		wr.elements[0] = weightedElement[V]{key: key, data: data}
+		heap.Fix(&wr.elements, 0)
+	}
+}
+
+// Elements return matched elements of the sample, the order is not specified
+func (wr *WeightedReservoir[V]) Elements(filter func(V) bool) (elements []V) {
+	wr.lock.Lock()
+	defer wr.lock.Unlock()
+
+	for _, ele := range wr.elements {
+		if filter == nil || filter(ele.data) {
+			elements = append(elements, ele.data)
+		}
+	}
+
+	return
+}
+
+// Size return the sample's elements count
+func (wr *WeightedReservoir[V]) Size() int {
+	wr.lock.Lock()
+	defer wr.lock.Unlock()
+
+	return len(wr.elements)
+}
+
+// Seen return the count of all pushed elements
+func (wr *WeightedReservoir[V]) Seen() uint64 {
+	wr.lock.Lock()
+	defer wr.lock.Unlock()
+
+	return wr.seen
+}
+
+type weightedHeap[V any] []weightedElement[V]
+
+func (h weightedHeap[V]) Len() int           { return len(h) }
+func (h weightedHeap[V]) Less(i, j int) bool { return h[i].key < h[j].key }
+func (h weightedHeap[V]) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *weightedHeap[V]) Push(x any)        { *h = append(*h, x.(weightedElement[V])) }
+func (h *weightedHeap[V]) Pop() any {
+	old := *h
+	e := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return e
+}
@@ -0,0 +1,129 @@
+package chper
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestReservoir(t *testing.T) {
+	for _, algorithm := range []ReservoirAlgorithm{ReservoirAlgorithmR, ReservoirAlgorithmL} {
+		r := NewReservoir[int](3, ReservoirOptionAlgorithm(algorithm), ReservoirOptionRandSource(rand.NewSource(1)))
+
+		r.Push(1)
+		r.Push(2)
+		if want, got := []int{1, 2}, r.Elements(nil); !reflect.DeepEqual(want, got) {
+			t.Errorf("algorithm: %d, want: %v, got: %v", algorithm, want, got)
+		}
+
+		for i := 3; i <= 100; i++ {
+			r.Push(i)
+		}
+		if r.Size() != 3 || r.Seen() != 100 {
+			t.Errorf("algorithm: %d, want: 3 100, got: %d %d", algorithm, r.Size(), r.Seen())
+		}
+		if got := SliceUnique(r.Elements(nil)); len(got) != 3 {
+			t.Errorf("algorithm: %d, want 3 distinct elements, got: %v", algorithm, got)
+		}
+		for _, v := range r.Elements(nil) {
+			if v < 1 || v > 100 {
+				t.Errorf("algorithm: %d, want element in [1, 100], got: %v", algorithm, v)
+			}
+		}
+		if got := r.Elements(func(v int) bool { return v > 100 }); got != nil {
+			t.Errorf("algorithm: %d, want nil, got: %v", algorithm, got)
+		}
+
+		// same seed, same sample
+		r2 := NewReservoir[int](3, ReservoirOptionAlgorithm(algorithm), ReservoirOptionRandSource(rand.NewSource(1)))
+		for i := 1; i <= 100; i++ {
+			r2.Push(i)
+		}
+		if want, got := r.Elements(nil), r2.Elements(nil); !reflect.DeepEqual(want, got) {
+			t.Errorf("algorithm: %d, want: %v, got: %v", algorithm, want, got)
+		}
+	}
+}
+
+func TestReservoirUniform(t *testing.T) {
+	const (
+		capacity = 5
+		stream   = 20
+		trials   = 20000
+	)
+
+	for _, algorithm := range []ReservoirAlgorithm{ReservoirAlgorithmR, ReservoirAlgorithmL} {
+		source := rand.NewSource(2)
+		counts := make([]int, stream)
+		for i := 0; i < trials; i++ {
+			r := NewReservoir[int](capacity, ReservoirOptionAlgorithm(algorithm), ReservoirOptionRandSource(source))
+			for j := 0; j < stream; j++ {
+				r.Push(j)
+			}
+			for _, v := range r.Elements(nil) {
+				counts[v]++
+			}
+		}
+
+		want := float64(trials) * capacity / stream
+		for v, count := range counts {
+			if math.Abs(float64(count)-want)/want > 0.05 {
+				t.Errorf("algorithm: %d, element: %d, want about: %.0f, got: %d", algorithm, v, want, count)
+			}
+		}
+	}
+}
+
+func TestWeightedReservoir(t *testing.T) {
+	wr := NewWeightedReservoir[string](2, ReservoirOptionRandSource(rand.NewSource(1)))
+
+	wr.Push("zero", 0)
+	wr.Push("negative", -1)
+	if wr.Size() != 0 || wr.Seen() != 2 {
+		t.Errorf("want: 0 2, got: %d %d", wr.Size(), wr.Seen())
+	}
+
+	wr.Push("a", 1)
+	wr.Push("b", 1)
+	got := wr.Elements(nil)
+	SliceSort(got)
+	if want := []string{"a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("want: %v, got: %v", want, got)
+	}
+
+	// weight 1, 2, 3, 4 with capacity 1: probability is proportional to weight
+	source := rand.NewSource(3)
+	counts := map[int]int{}
+	const trials = 20000
+	for i := 0; i < trials; i++ {
+		wr := NewWeightedReservoir[int](1, ReservoirOptionRandSource(source))
+		for w := 1; w <= 4; w++ {
+			wr.Push(w, float64(w))
+		}
+		counts[wr.Elements(nil)[0]]++
+	}
+	for w := 1; w <= 4; w++ {
+		want := float64(trials) * float64(w) / 10
+		if math.Abs(float64(counts[w])-want)/want > 0.08 {
+			t.Errorf("weight: %d, want about: %.0f, got: %d", w, want, counts[w])
+		}
+	}
+}
+
+func BenchmarkReservoir(b *testing.B) {
+	for _, cas := range []struct {
+		name      string
+		algorithm ReservoirAlgorithm
+	}{
+		{name: "R", algorithm: ReservoirAlgorithmR},
+		{name: "L", algorithm: ReservoirAlgorithmL},
+	} {
+		b.Run(cas.name, func(b *testing.B) {
+			r := NewReservoir[int](100, ReservoirOptionAlgorithm(cas.algorithm))
+			for i := 0; i < b.N; i++ {
+				r.Push(i)
+			}
+		})
+	}
+}
@@ -5,6 +5,7 @@ import "sync"
 // Ring is a sorted set with fixed capcity
 // all element sorted by Push index
 // early elelements will be removed if Ring is filled
+// duplicate elements are kept, use SetRing if they are not wanted
 type Ring[V any] struct {
 	capacity int
 	begin    int
@@ -13,19 +14,49 @@ type Ring[V any] struct {
 	lock sync.Mutex
 
 	elements []V
+
+	option *ringOption[V]
+
+	pushed    uint64
+	evicted   uint64
+	highWater int
+
+	subscribers map[*ringSubscriber[V]]struct{}
+}
+
+type ringOption[V any] struct {
+	onEvict func(V)
+}
+
+type ringOptionFunc[V any] func(*ringOption[V])
+
+// RingOptionOnEvict specify the callback of evicted elements
+// an element is evicted when Push overwrite it or Resize drop it, it is not evicted by Pop or Clear
+// onEvict is called outside the lock after Push or Resize, so it can call Ring's methods
+func RingOptionOnEvict[V any](onEvict func(V)) ringOptionFunc[V] {
+	return func(ro *ringOption[V]) {
+		ro.onEvict = onEvict
+	}
 }
 
-func NewRing[V any](capacity int) *Ring[V] {
+func NewRing[V any](capacity int, options ...ringOptionFunc[V]) *Ring[V] {
 	if capacity < 1 {
 		panic("bad capacity")
 	}
 
+	option := &ringOption[V]{}
+	for _, f := range options {
+		f(option)
+	}
+
 	return &Ring[V]{
 		capacity: capacity,
 		begin:    0,
 		size:     0,
 
 		elements: make([]V, capacity),
+
+		option: option,
 	}
 }
 
@@ -42,20 +73,139 @@ func NewRing[V any](capacity int) *Ring[V] {
 */
 func (r *Ring[V]) Push(data V) {
 	r.lock.Lock()
+	evicted, ok := r.push(data)
+	r.lock.Unlock()
+
+	if ok && r.option.onEvict != nil {
+		r.option.onEvict(evicted)
+	}
+}
+
+// push return the evicted element and whether there is one
+func (r *Ring[V]) push(data V) (evicted V, ok bool) {
+	r.pushed++
 
 	if r.capacity == r.size { // 满了
+		evicted, ok = r.elements[r.begin], true
+		r.evicted++
+
 		r.elements[r.begin] = data // 覆盖当前的值
 
 		r.begin++
 		r.begin %= r.capacity
 	} else {
-		r.elements[r.begin+r.size] = data
+		r.elements[(r.begin+r.size)%r.capacity] = data
 		r.size++
+
+		if r.size > r.highWater {
+			r.highWater = r.size
+		}
 	}
 
+	r.publish(data)
+
+	return
+}
+
+// PopFront remove and return the first(oldest) element
+func (r *Ring[V]) PopFront() (V, bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return r.popFront()
+}
+
+func (r *Ring[V]) popFront() (v V, ok bool) {
+	if r.size == 0 {
+		return
+	}
+
+	var zero V
+	v, r.elements[r.begin] = r.elements[r.begin], zero // 置空，让GC回收
+
+	r.begin++
+	r.begin %= r.capacity
+	r.size--
+
+	return v, true
+}
+
+// PopBack remove and return the last(newest) element
+func (r *Ring[V]) PopBack() (V, bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return r.popBack()
+}
+
+func (r *Ring[V]) popBack() (v V, ok bool) {
+	if r.size == 0 {
+		return
+	}
+
+	var zero V
+	i := (r.begin + r.size - 1) % r.capacity
+	v, r.elements[i] = r.elements[i], zero
+
+	r.size--
+
+	return v, true
+}
+
+// Drain remove and return at most n first elements, sorted by push index
+// n < 0 means all elements
+func (r *Ring[V]) Drain(n int) []V {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if n < 0 || n > r.size {
+		n = r.size
+	}
+
+	elements := make([]V, 0, n)
+	for i := 0; i < n; i++ {
+		v, _ := r.popFront()
+		elements = append(elements, v)
+	}
+
+	return elements
+}
+
+// PeekN return at most n first elements without removing them, sorted by push index
+// n < 0 means all elements
+func (r *Ring[V]) PeekN(n int) []V {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if n < 0 || n > r.size {
+		n = r.size
+	}
+
+	elements := make([]V, 0, n)
+	for i := 0; i < n; i++ {
+		elements = append(elements, r.at(i))
+	}
+
+	return elements
+}
+
+// Clear remove all elements
+func (r *Ring[V]) Clear() {
+	r.lock.Lock()
+	r.clear()
 	r.lock.Unlock()
 }
 
+func (r *Ring[V]) clear() {
+	var zero V
+	for i := range r.elements {
+		r.elements[i] = zero
+	}
+
+	r.begin = 0
+	r.size = 0
+}
+
 // Elements return matched elements, sorted by push index
 func (r *Ring[V]) Elements(filter func(V) bool) (elements []V) {
 	r.lock.Lock()
@@ -71,6 +221,52 @@ func (r *Ring[V]) Elements(filter func(V) bool) (elements []V) {
 	return
 }
 
+// at return the i-th element from the first one, i must be in [0, size)
+func (r *Ring[V]) at(i int) V {
+	return r.elements[(r.begin+i)%r.capacity]
+}
+
+// At return the i-th element from the first(oldest) one and exist
+// negative i counts from the last(newest) one, -1 is the last element
+func (r *Ring[V]) At(i int) (v V, ok bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if i < 0 {
+		i += r.size
+	}
+	if i < 0 || i >= r.size {
+		return
+	}
+
+	return r.at(i), true
+}
+
+// Range call f for each element from the first one, i is the logical index, stop if f return false
+// elements are not copied, the Ring is locked during Range, so f must not call Ring's methods
+func (r *Ring[V]) Range(f func(i int, v V) bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	for i := 0; i < r.size; i++ {
+		if !f(i, r.at(i)) {
+			return
+		}
+	}
+}
+
+// RangeReverse is the same as Range, but from the last element
+func (r *Ring[V]) RangeReverse(f func(i int, v V) bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	for i := r.size - 1; i >= 0; i-- {
+		if !f(i, r.at(i)) {
+			return
+		}
+	}
+}
+
 // First return first element and exist
 func (r *Ring[V]) First() (V, bool) {
 	r.lock.Lock()
@@ -98,3 +294,79 @@ func (r *Ring[V]) Size() int {
 
 	return r.size
 }
+
+// Capacity return ring's capacity
+func (r *Ring[V]) Capacity() int {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return r.capacity
+}
+
+// Resize change ring's capacity
+// shrinking keeps the newest elements, growing keeps all elements, both keep the push order
+func (r *Ring[V]) Resize(capacity int) {
+	if capacity < 1 {
+		panic("bad capacity")
+	}
+
+	r.lock.Lock()
+	evicted := r.resize(capacity)
+	r.lock.Unlock()
+
+	if r.option.onEvict != nil {
+		for _, v := range evicted {
+			r.option.onEvict(v)
+		}
+	}
+}
+
+// resize return the dropped elements, sorted by push index
+func (r *Ring[V]) resize(capacity int) (evicted []V) {
+	size := r.size
+	if size > capacity {
+		size = capacity
+	}
+
+	if r.size > size {
+		evicted = make([]V, 0, r.size-size)
+		for i := 0; i < r.size-size; i++ {
+			evicted = append(evicted, r.at(i))
+		}
+		r.evicted += uint64(len(evicted))
+	}
+
+	elements := make([]V, capacity)
+	for i := 0; i < size; i++ {
+		elements[i] = r.at(r.size - size + i)
+	}
+
+	r.elements = elements
+	r.capacity = capacity
+	r.begin = 0
+	r.size = size
+
+	return
+}
+
+// RingStats is the counters of Ring
+type RingStats struct {
+	// Pushed is the total count of pushed elements
+	Pushed uint64
+	// Evicted is the total count of evicted elements
+	Evicted uint64
+	// HighWater is the max size ever reached
+	HighWater int
+}
+
+// Stats return ring's counters
+func (r *Ring[V]) Stats() RingStats {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return RingStats{
+		Pushed:    r.pushed,
+		Evicted:   r.evicted,
+		HighWater: r.highWater,
+	}
+}
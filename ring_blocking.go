@@ -0,0 +1,187 @@
+package chper
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	ErrRingClosed = errors.New("ring closed")
+	ErrRingFull   = errors.New("ring full")
+)
+
+// OverflowPolicy specify what Push does when ring is filled
+type OverflowPolicy int
+
+const (
+	// OverflowOverwrite overwrite the oldest element, it is the same as Ring
+	OverflowOverwrite OverflowPolicy = iota
+	// OverflowDropNewest drop the pushing element, Push return ErrRingFull
+	OverflowDropNewest
+	// OverflowBlock block Push until there is free space or ctx is done
+	OverflowBlock
+)
+
+// BlockingRing is a bounded queue built on Ring, it works like a buffered channel
+// Pop block until there is an element, Push behaves as OverflowPolicy when ring is filled
+// after Close, Push return ErrRingClosed, Pop return the remaining elements then ErrRingClosed
+type BlockingRing[V any] struct {
+	ring   *Ring[V]
+	policy OverflowPolicy
+
+	closed bool
+	// notEmpty and notFull are closed and replaced to wake up all waiters, protected by ring.lock
+	// they are replaced only if there are waiters, so Push and Pop do not allocate
+	notEmpty    chan struct{}
+	notFull     chan struct{}
+	popWaiters  int
+	pushWaiters int
+}
+
+func NewBlockingRing[V any](capacity int, policy OverflowPolicy) *BlockingRing[V] {
+	return &BlockingRing[V]{
+		ring:   NewRing[V](capacity),
+		policy: policy,
+
+		notEmpty: make(chan struct{}),
+		notFull:  make(chan struct{}),
+	}
+}
+
+// Push append one element, it may block if policy is OverflowBlock
+func (b *BlockingRing[V]) Push(ctx context.Context, data V) error {
+	for {
+		b.ring.lock.Lock()
+		if b.closed {
+			b.ring.lock.Unlock()
+			return ErrRingClosed
+		}
+
+		if b.ring.size < b.ring.capacity || b.policy == OverflowOverwrite {
+			b.ring.push(data)
+			b.broadcast(&b.notEmpty, b.popWaiters)
+			b.ring.lock.Unlock()
+			return nil
+		}
+
+		if b.policy == OverflowDropNewest {
+			b.ring.lock.Unlock()
+			return ErrRingFull
+		}
+
+		wait := b.notFull
+		b.pushWaiters++
+		b.ring.lock.Unlock()
+
+		var err error
+		select {
+		case <-ctx.Done():
+			err = ctx.Err()
+		case <-wait:
+		}
+
+		b.ring.lock.Lock()
+		b.pushWaiters--
+		b.ring.lock.Unlock()
+
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Pop remove and return the first element, it blocks until there is an element
+func (b *BlockingRing[V]) Pop(ctx context.Context) (v V, err error) {
+	for {
+		b.ring.lock.Lock()
+		if data, ok := b.ring.popFront(); ok {
+			b.broadcast(&b.notFull, b.pushWaiters)
+			b.ring.lock.Unlock()
+			return data, nil
+		}
+
+		if b.closed {
+			b.ring.lock.Unlock()
+			err = ErrRingClosed
+			return
+		}
+
+		wait := b.notEmpty
+		b.popWaiters++
+		b.ring.lock.Unlock()
+
+		select {
+		case <-ctx.Done():
+			err = ctx.Err()
+		case <-wait:
+		}
+
+		b.ring.lock.Lock()
+		b.popWaiters--
+		b.ring.lock.Unlock()
+
+		if err != nil {
+			return
+		}
+	}
+}
+
+// TryPop remove and return the first element without blocking
+func (b *BlockingRing[V]) TryPop() (V, bool) {
+	b.ring.lock.Lock()
+	defer b.ring.lock.Unlock()
+
+	v, ok := b.ring.popFront()
+	if ok {
+		b.broadcast(&b.notFull, b.pushWaiters)
+	}
+
+	return v, ok
+}
+
+// Close wake up all blocked Push and Pop, it is safe to call Close more than once
+func (b *BlockingRing[V]) Close() {
+	b.ring.lock.Lock()
+	defer b.ring.lock.Unlock()
+
+	if b.closed {
+		return
+	}
+	b.closed = true
+
+	b.broadcast(&b.notEmpty, b.popWaiters)
+	b.broadcast(&b.notFull, b.pushWaiters)
+}
+
+// Closed return whether Close is called
+func (b *BlockingRing[V]) Closed() bool {
+	b.ring.lock.Lock()
+	defer b.ring.lock.Unlock()
+
+	return b.closed
+}
+
+// Elements return matched elements, sorted by push index
+func (b *BlockingRing[V]) Elements(filter func(V) bool) []V {
+	return b.ring.Elements(filter)
+}
+
+// Size return ring's elements count
+func (b *BlockingRing[V]) Size() int {
+	return b.ring.Size()
+}
+
+// Capacity return ring's capacity
+func (b *BlockingRing[V]) Capacity() int {
+	return b.ring.capacity
+}
+
+// broadcast wake up waiters, it must be called with ring.lock held
+func (b *BlockingRing[V]) broadcast(ch *chan struct{}, waiters int) {
+	if waiters == 0 {
+		return
+	}
+
+	close(*ch)
+	*ch = make(chan struct{})
+}
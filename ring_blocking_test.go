@@ -0,0 +1,184 @@
+package chper
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBlockingRingPolicy(t *testing.T) {
+	ctx := context.Background()
+
+	for _, cas := range []struct {
+		name    string
+		policy  OverflowPolicy
+		wantErr error
+		want    []int
+	}{
+		{name: "overwrite", policy: OverflowOverwrite, wantErr: nil, want: []int{2, 3}},
+		{name: "drop newest", policy: OverflowDropNewest, wantErr: ErrRingFull, want: []int{1, 2}},
+	} {
+		b := NewBlockingRing[int](2, cas.policy)
+		b.Push(ctx, 1)
+		b.Push(ctx, 2)
+		if err := b.Push(ctx, 3); !errors.Is(err, cas.wantErr) {
+			t.Errorf("%s, want: %v, got: %v", cas.name, cas.wantErr, err)
+		}
+		if got := b.Elements(nil); !reflect.DeepEqual(got, cas.want) {
+			t.Errorf("%s, want: %v, got: %v", cas.name, cas.want, got)
+		}
+	}
+
+	// block
+	{
+		b := NewBlockingRing[int](2, OverflowBlock)
+		b.Push(ctx, 1)
+		b.Push(ctx, 2)
+
+		timeout, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+		if err := b.Push(timeout, 3); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("want: %v, got: %v", context.DeadlineExceeded, err)
+		}
+
+		done := make(chan error)
+		go func() { done <- b.Push(ctx, 3) }()
+
+		v, err := b.Pop(ctx)
+		if err != nil || v != 1 {
+			t.Errorf("want: 1, got: %v, %v", v, err)
+		}
+		if err := <-done; err != nil {
+			t.Errorf("want nil, got: %v", err)
+		}
+		if got, want := b.Elements(nil), []int{2, 3}; !reflect.DeepEqual(got, want) {
+			t.Errorf("want: %v, got: %v", want, got)
+		}
+		if b.Size() != 2 || b.Capacity() != 2 {
+			t.Errorf("want: 2 2, got: %v %v", b.Size(), b.Capacity())
+		}
+	}
+}
+
+func TestBlockingRingPop(t *testing.T) {
+	ctx := context.Background()
+	b := NewBlockingRing[int](2, OverflowBlock)
+
+	if _, ok := b.TryPop(); ok {
+		t.Errorf("want: false, got: %v", ok)
+	}
+
+	timeout, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if _, err := b.Pop(timeout); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("want: %v, got: %v", context.DeadlineExceeded, err)
+	}
+
+	done := make(chan int)
+	go func() {
+		v, _ := b.Pop(ctx)
+		done <- v
+	}()
+	time.Sleep(time.Millisecond)
+	b.Push(ctx, 7)
+	if got := <-done; got != 7 {
+		t.Errorf("want: 7, got: %v", got)
+	}
+
+	b.Push(ctx, 8)
+	if v, ok := b.TryPop(); !ok || v != 8 {
+		t.Errorf("want: 8, got: %v, %v", v, ok)
+	}
+}
+
+func TestBlockingRingClose(t *testing.T) {
+	ctx := context.Background()
+
+	// blocked Pop and Push are woken up
+	{
+		b := NewBlockingRing[int](1, OverflowBlock)
+		popDone := make(chan error)
+		go func() {
+			_, err := b.Pop(ctx)
+			popDone <- err
+		}()
+		time.Sleep(time.Millisecond)
+		b.Close()
+		if err := <-popDone; !errors.Is(err, ErrRingClosed) {
+			t.Errorf("want: %v, got: %v", ErrRingClosed, err)
+		}
+	}
+	{
+		b := NewBlockingRing[int](1, OverflowBlock)
+		b.Push(ctx, 1)
+		pushDone := make(chan error)
+		go func() { pushDone <- b.Push(ctx, 2) }()
+		time.Sleep(time.Millisecond)
+		b.Close()
+		b.Close()
+		if err := <-pushDone; !errors.Is(err, ErrRingClosed) {
+			t.Errorf("want: %v, got: %v", ErrRingClosed, err)
+		}
+		if !b.Closed() {
+			t.Errorf("want: true, got: false")
+		}
+
+		// remaining elements can be popped
+		if v, err := b.Pop(ctx); err != nil || v != 1 {
+			t.Errorf("want: 1, got: %v, %v", v, err)
+		}
+		if _, err := b.Pop(ctx); !errors.Is(err, ErrRingClosed) {
+			t.Errorf("want: %v, got: %v", ErrRingClosed, err)
+		}
+		if err := b.Push(ctx, 3); !errors.Is(err, ErrRingClosed) {
+			t.Errorf("want: %v, got: %v", ErrRingClosed, err)
+		}
+	}
+}
+
+func TestBlockingRingConcurrent(t *testing.T) {
+	ctx := context.Background()
+	b := NewBlockingRing[int](4, OverflowBlock)
+
+	producers, perProducer := 4, 1000
+	var wg sync.WaitGroup
+	for p := 0; p < producers; p++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perProducer; i++ {
+				if err := b.Push(ctx, 1); err != nil {
+					t.Errorf("want nil, got: %v", err)
+				}
+			}
+		}()
+	}
+
+	sums := make(chan int)
+	for c := 0; c < 3; c++ {
+		go func() {
+			sum := 0
+			for {
+				v, err := b.Pop(ctx)
+				if err != nil {
+					sums <- sum
+					return
+				}
+				sum += v
+			}
+		}()
+	}
+
+	wg.Wait()
+	b.Close()
+	total := 0
+	for c := 0; c < 3; c++ {
+		total += <-sums
+	}
+	if total != producers*perProducer {
+		t.Errorf("want: %v, got: %v", producers*perProducer, total)
+	}
+}
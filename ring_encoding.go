@@ -0,0 +1,100 @@
+package chper
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+)
+
+// ringSnapshot is the serialized form of Ring, elements are sorted by push index
+type ringSnapshot[V any] struct {
+	Capacity int `json:"capacity"`
+	Elements []V `json:"elements"`
+}
+
+func (r *Ring[V]) snapshot() ringSnapshot[V] {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	elements := make([]V, 0, r.size)
+	for i := 0; i < r.size; i++ {
+		elements = append(elements, r.at(i))
+	}
+
+	return ringSnapshot[V]{Capacity: r.capacity, Elements: elements}
+}
+
+// restore replace ring's capacity and elements, the option and counters are kept
+func (r *Ring[V]) restore(s ringSnapshot[V]) error {
+	if s.Capacity < 1 {
+		return fmt.Errorf("bad capacity: %d", s.Capacity)
+	}
+	if len(s.Elements) > s.Capacity {
+		return fmt.Errorf("elements count %d is larger than capacity %d", len(s.Elements), s.Capacity)
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	// a zero value Ring has no option
+	if r.option == nil {
+		r.option = &ringOption[V]{}
+	}
+
+	r.elements = make([]V, s.Capacity)
+	copy(r.elements, s.Elements)
+	r.capacity = s.Capacity
+	r.begin = 0
+	r.size = len(s.Elements)
+	if r.size > r.highWater {
+		r.highWater = r.size
+	}
+
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler, the output is {"capacity":3,"elements":[...]}
+func (r *Ring[V]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.snapshot())
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (r *Ring[V]) UnmarshalJSON(data []byte) error {
+	s := ringSnapshot[V]{}
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	return r.restore(s)
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler, elements are encoded by encoding/gob
+func (r *Ring[V]) MarshalBinary() ([]byte, error) {
+	buf := bytes.Buffer{}
+	if err := gob.NewEncoder(&buf).Encode(r.snapshot()); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler
+func (r *Ring[V]) UnmarshalBinary(data []byte) error {
+	s := ringSnapshot[V]{}
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&s); err != nil {
+		return err
+	}
+
+	return r.restore(s)
+}
+
+// GobEncode implements gob.GobEncoder
+func (r *Ring[V]) GobEncode() ([]byte, error) {
+	return r.MarshalBinary()
+}
+
+// GobDecode implements gob.GobDecoder
+func (r *Ring[V]) GobDecode(data []byte) error {
+	return r.UnmarshalBinary(data)
+}
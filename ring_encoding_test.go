@@ -0,0 +1,115 @@
+package chper
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/gob"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+var (
+	_ json.Marshaler             = (*Ring[int])(nil)
+	_ json.Unmarshaler           = (*Ring[int])(nil)
+	_ encoding.BinaryMarshaler   = (*Ring[int])(nil)
+	_ encoding.BinaryUnmarshaler = (*Ring[int])(nil)
+	_ gob.GobEncoder             = (*Ring[int])(nil)
+	_ gob.GobDecoder             = (*Ring[int])(nil)
+)
+
+func newEncodingTestRing() *Ring[Node] {
+	r := NewRing[Node](3)
+	r.Push(*nodeA)
+	r.Push(*nodeB)
+	r.Push(*nodeC)
+	r.Push(*nodeD) // wrapped: nodeB nodeC nodeD
+	return r
+}
+
+func checkRestoredRing(t *testing.T, r *Ring[Node]) {
+	t.Helper()
+
+	if want, got := []Node{*nodeB, *nodeC, *nodeD}, r.Elements(nil); !reflect.DeepEqual(want, got) {
+		t.Errorf("want: %v, got: %v", want, got)
+	}
+	if got := r.Capacity(); got != 3 {
+		t.Errorf("want: 3, got: %v", got)
+	}
+
+	r.Push(*nodeA)
+	if want, got := []Node{*nodeC, *nodeD, *nodeA}, r.Elements(nil); !reflect.DeepEqual(want, got) {
+		t.Errorf("want: %v, got: %v", want, got)
+	}
+}
+
+func TestRingJSON(t *testing.T) {
+	bs, err := json.Marshal(newEncodingTestRing())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := &Ring[Node]{}
+	if err := json.Unmarshal(bs, r); err != nil {
+		t.Fatal(err)
+	}
+	checkRestoredRing(t, r)
+
+	// as a field of status snapshot
+	status := struct {
+		Recent *Ring[int] `json:"recent"`
+	}{Recent: NewRing[int](2)}
+	status.Recent.Push(1)
+	bs, _ = json.Marshal(status)
+	if want, got := `{"recent":{"capacity":2,"elements":[1]}}`, string(bs); want != got {
+		t.Errorf("want: %v, got: %v", want, got)
+	}
+
+	for _, bad := range []string{
+		`{"capacity":0,"elements":[]}`,
+		`{"capacity":1,"elements":[1,2]}`,
+		`[1,2]`,
+	} {
+		if err := json.Unmarshal([]byte(bad), &Ring[int]{}); err == nil {
+			t.Errorf("want error, got nil, data: %s", bad)
+		}
+	}
+}
+
+func TestRingBinary(t *testing.T) {
+	bs, err := newEncodingTestRing().MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewRing[Node](1)
+	if err := r.UnmarshalBinary(bs); err != nil {
+		t.Fatal(err)
+	}
+	checkRestoredRing(t, r)
+
+	if err := r.UnmarshalBinary([]byte("bad")); err == nil {
+		t.Errorf("want error, got nil")
+	}
+}
+
+func TestRingGob(t *testing.T) {
+	type status struct {
+		Name   string
+		Recent *Ring[Node]
+	}
+
+	buf := bytes.Buffer{}
+	if err := gob.NewEncoder(&buf).Encode(status{Name: "a", Recent: newEncodingTestRing()}); err != nil {
+		t.Fatal(err)
+	}
+
+	got := status{}
+	if err := gob.NewDecoder(&buf).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != "a" {
+		t.Errorf("want: a, got: %v", got.Name)
+	}
+	checkRestoredRing(t, got.Recent)
+}
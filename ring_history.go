@@ -0,0 +1,92 @@
+package chper
+
+// History is a bounded undo/redo history built on Ring
+// entries [0, applied) can be undone, entries [applied, size) can be redone
+// Do after Undo discards the redo entries, the oldest entries are evicted when Ring is filled
+type History[V any] struct {
+	ring    *Ring[V]
+	applied int
+}
+
+func NewHistory[V any](capacity int) *History[V] {
+	return &History[V]{
+		ring: NewRing[V](capacity),
+	}
+}
+
+// Do record one action, the redo entries are discarded
+func (h *History[V]) Do(v V) {
+	h.ring.lock.Lock()
+	defer h.ring.lock.Unlock()
+
+	for h.ring.size > h.applied {
+		h.ring.popBack()
+	}
+
+	h.ring.push(v)
+	h.applied = h.ring.size
+}
+
+// Undo return the last done action and exist, it becomes the first redo entry
+func (h *History[V]) Undo() (v V, ok bool) {
+	h.ring.lock.Lock()
+	defer h.ring.lock.Unlock()
+
+	if h.applied == 0 {
+		return
+	}
+
+	h.applied--
+	return h.ring.at(h.applied), true
+}
+
+// Redo return the last undone action and exist, it is done again
+func (h *History[V]) Redo() (v V, ok bool) {
+	h.ring.lock.Lock()
+	defer h.ring.lock.Unlock()
+
+	if h.applied == h.ring.size {
+		return
+	}
+
+	h.applied++
+	return h.ring.at(h.applied - 1), true
+}
+
+// CanUndo return whether there is an action to undo
+func (h *History[V]) CanUndo() bool {
+	h.ring.lock.Lock()
+	defer h.ring.lock.Unlock()
+
+	return h.applied > 0
+}
+
+// CanRedo return whether there is an action to redo
+func (h *History[V]) CanRedo() bool {
+	h.ring.lock.Lock()
+	defer h.ring.lock.Unlock()
+
+	return h.applied < h.ring.size
+}
+
+// Done return the done actions, sorted by Do index
+func (h *History[V]) Done() []V {
+	h.ring.lock.Lock()
+	defer h.ring.lock.Unlock()
+
+	done := make([]V, 0, h.applied)
+	for i := 0; i < h.applied; i++ {
+		done = append(done, h.ring.at(i))
+	}
+
+	return done
+}
+
+// Clear remove all actions
+func (h *History[V]) Clear() {
+	h.ring.lock.Lock()
+	defer h.ring.lock.Unlock()
+
+	h.ring.clear()
+	h.applied = 0
+}
@@ -0,0 +1,99 @@
+package chper
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestHistory(t *testing.T) {
+	h := NewHistory[string](3)
+
+	if _, ok := h.Undo(); ok || h.CanUndo() || h.CanRedo() {
+		t.Errorf("want nothing to undo or redo")
+	}
+
+	h.Do("a")
+	h.Do("b")
+	h.Do("c")
+	h.Do("d") // a is evicted
+	if want, got := []string{"b", "c", "d"}, h.Done(); !reflect.DeepEqual(want, got) {
+		t.Errorf("want: %v, got: %v", want, got)
+	}
+
+	for _, want := range []string{"d", "c"} {
+		if got, ok := h.Undo(); !ok || got != want {
+			t.Errorf("undo, want: %v, got: %v %v", want, got, ok)
+		}
+	}
+	if !h.CanUndo() || !h.CanRedo() {
+		t.Errorf("want can undo and redo")
+	}
+
+	if got, ok := h.Redo(); !ok || got != "c" {
+		t.Errorf("redo, want: c, got: %v %v", got, ok)
+	}
+	if want, got := []string{"b", "c"}, h.Done(); !reflect.DeepEqual(want, got) {
+		t.Errorf("want: %v, got: %v", want, got)
+	}
+
+	// branching discards d
+	h.Do("e")
+	if h.CanRedo() {
+		t.Errorf("want can not redo")
+	}
+	if _, ok := h.Redo(); ok {
+		t.Errorf("want nothing to redo")
+	}
+	if want, got := []string{"b", "c", "e"}, h.Done(); !reflect.DeepEqual(want, got) {
+		t.Errorf("want: %v, got: %v", want, got)
+	}
+
+	for _, want := range []string{"e", "c", "b"} {
+		if got, ok := h.Undo(); !ok || got != want {
+			t.Errorf("undo, want: %v, got: %v %v", want, got, ok)
+		}
+	}
+	if _, ok := h.Undo(); ok || h.CanUndo() {
+		t.Errorf("want nothing to undo")
+	}
+	for _, want := range []string{"b", "c", "e"} {
+		if got, ok := h.Redo(); !ok || got != want {
+			t.Errorf("redo, want: %v, got: %v %v", want, got, ok)
+		}
+	}
+
+	h.Clear()
+	if h.CanUndo() || h.CanRedo() || len(h.Done()) != 0 {
+		t.Errorf("want empty, got: %v", h.Done())
+	}
+}
+
+func TestHistoryConcurrent(t *testing.T) {
+	h := NewHistory[int](8)
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				switch j % 3 {
+				case 0:
+					h.Do(j)
+				case 1:
+					h.Undo()
+				default:
+					h.Redo()
+				}
+				h.CanUndo()
+				h.CanRedo()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if got := len(h.Done()); got > 8 {
+		t.Errorf("want at most 8, got: %v", got)
+	}
+}
@@ -0,0 +1,29 @@
+package chper
+
+import "iter"
+
+// All return an iterator over index and element, from the first element
+// the Ring is locked during the iteration, so the loop body must not call Ring's methods
+func (r *Ring[V]) All() iter.Seq2[int, V] {
+	return func(yield func(int, V) bool) {
+		r.Range(yield)
+	}
+}
+
+// Values return an iterator over elements, from the first element
+// the Ring is locked during the iteration, so the loop body must not call Ring's methods
+func (r *Ring[V]) Values() iter.Seq[V] {
+	return func(yield func(V) bool) {
+		r.Range(func(_ int, v V) bool {
+			return yield(v)
+		})
+	}
+}
+
+// Backward return an iterator over index and element, from the last element
+// the Ring is locked during the iteration, so the loop body must not call Ring's methods
+func (r *Ring[V]) Backward() iter.Seq2[int, V] {
+	return func(yield func(int, V) bool) {
+		r.RangeReverse(yield)
+	}
+}
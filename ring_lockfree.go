@@ -0,0 +1,181 @@
+package chper
+
+import "sync/atomic"
+
+// cacheLinePad prevents false sharing between hot fields
+type cacheLinePad [64]byte
+
+// roundUpPowerOfTwo return the smallest power of two which is not less than n
+func roundUpPowerOfTwo(n int) uint64 {
+	size := uint64(1)
+	for size < uint64(n) {
+		size <<= 1
+	}
+
+	return size
+}
+
+// SPSCRing is a lock-free bounded queue for single producer and single consumer
+// capacity is rounded up to power of two
+// unlike Ring, Push fail instead of overwriting when it is filled
+type SPSCRing[V any] struct {
+	_ cacheLinePad
+	// head is the next read index, written by the consumer
+	head uint64
+	_    cacheLinePad
+	// tail is the next write index, written by the producer
+	tail uint64
+	_    cacheLinePad
+
+	mask     uint64
+	elements []V
+}
+
+func NewSPSCRing[V any](capacity int) *SPSCRing[V] {
+	if capacity < 1 {
+		panic("bad capacity")
+	}
+
+	size := roundUpPowerOfTwo(capacity)
+	return &SPSCRing[V]{
+		mask:     size - 1,
+		elements: make([]V, size),
+	}
+}
+
+// Push append one element, return false if SPSCRing is filled
+// it must be called by only one goroutine at the same time
+func (r *SPSCRing[V]) Push(data V) bool {
+	tail := atomic.LoadUint64(&r.tail)
+	if tail-atomic.LoadUint64(&r.head) > r.mask {
+		return false
+	}
+
+	r.elements[tail&r.mask] = data
+	atomic.StoreUint64(&r.tail, tail+1)
+
+	return true
+}
+
+// Pop remove and return the first element, return false if SPSCRing is empty
+// it must be called by only one goroutine at the same time
+func (r *SPSCRing[V]) Pop() (v V, ok bool) {
+	head := atomic.LoadUint64(&r.head)
+	if head == atomic.LoadUint64(&r.tail) {
+		return
+	}
+
+	var zero V
+	i := head & r.mask
+	v, r.elements[i] = r.elements[i], zero
+	atomic.StoreUint64(&r.head, head+1)
+
+	return v, true
+}
+
+// Size return elements count, it may be stale under concurrent access
+func (r *SPSCRing[V]) Size() int {
+	head := atomic.LoadUint64(&r.head)
+	return int(atomic.LoadUint64(&r.tail) - head)
+}
+
+// Capacity return the rounded up capacity
+func (r *SPSCRing[V]) Capacity() int {
+	return int(r.mask + 1)
+}
+
+// MPMCRing is a lock-free bounded queue for multiple producers and multiple consumers
+// it is Dmitry Vyukov's bounded MPMC queue, capacity is rounded up to power of two
+// unlike Ring, Push fail instead of overwriting when it is filled
+type MPMCRing[V any] struct {
+	_ cacheLinePad
+	// enqueuePos is the next write position
+	enqueuePos uint64
+	_          cacheLinePad
+	// dequeuePos is the next read position
+	dequeuePos uint64
+	_          cacheLinePad
+
+	mask uint64
+	// sequences[i] tells the state of elements[i]:
+	// == pos means it is free for position pos, == pos+1 means it is filled by position pos
+	sequences []uint64
+	elements  []V
+}
+
+func NewMPMCRing[V any](capacity int) *MPMCRing[V] {
+	if capacity < 1 {
+		panic("bad capacity")
+	}
+
+	size := roundUpPowerOfTwo(capacity)
+	r := &MPMCRing[V]{
+		mask:      size - 1,
+		sequences: make([]uint64, size),
+		elements:  make([]V, size),
+	}
+	for i := range r.sequences {
+		r.sequences[i] = uint64(i)
+	}
+
+	return r
+}
+
+// Push append one element, return false if MPMCRing is filled
+func (r *MPMCRing[V]) Push(data V) bool {
+	pos := atomic.LoadUint64(&r.enqueuePos)
+	for {
+		i := pos & r.mask
+		diff := int64(atomic.LoadUint64(&r.sequences[i]) - pos)
+
+		if diff == 0 {
+			if atomic.CompareAndSwapUint64(&r.enqueuePos, pos, pos+1) {
+				r.elements[i] = data
+				atomic.StoreUint64(&r.sequences[i], pos+1)
+				return true
+			}
+		} else if diff < 0 {
+			return false
+		}
+
+		pos = atomic.LoadUint64(&r.enqueuePos)
+	}
+}
+
+// Pop remove and return the first element, return false if MPMCRing is empty
+func (r *MPMCRing[V]) Pop() (v V, ok bool) {
+	pos := atomic.LoadUint64(&r.dequeuePos)
+	for {
+		i := pos & r.mask
+		diff := int64(atomic.LoadUint64(&r.sequences[i]) - (pos + 1))
+
+		if diff == 0 {
+			if atomic.CompareAndSwapUint64(&r.dequeuePos, pos, pos+1) {
+				var zero V
+				v, r.elements[i] = r.elements[i], zero
+				atomic.StoreUint64(&r.sequences[i], pos+r.mask+1)
+				return v, true
+			}
+		} else if diff < 0 {
+			return
+		}
+
+		pos = atomic.LoadUint64(&r.dequeuePos)
+	}
+}
+
+// Size return elements count, it may be stale under concurrent access
+func (r *MPMCRing[V]) Size() int {
+	dequeue := atomic.LoadUint64(&r.dequeuePos)
+	enqueue := atomic.LoadUint64(&r.enqueuePos)
+	if enqueue < dequeue {
+		return 0
+	}
+
+	return int(enqueue - dequeue)
+}
+
+// Capacity return the rounded up capacity
+func (r *MPMCRing[V]) Capacity() int {
+	return int(r.mask + 1)
+}
@@ -0,0 +1,243 @@
+package chper
+
+import (
+	"reflect"
+	"runtime"
+	"sync"
+	"testing"
+)
+
+func TestSPSCRing(t *testing.T) {
+	ring := NewSPSCRing[int](3)
+	if ring.Capacity() != 4 {
+		t.Errorf("want: 4, got: %v", ring.Capacity())
+	}
+
+	if _, ok := ring.Pop(); ok {
+		t.Errorf("want: false, got: %v", ok)
+	}
+
+	got := []int{}
+	for round := 0; round < 3; round++ {
+		for i := 0; i < 4; i++ {
+			if !ring.Push(round*10 + i) {
+				t.Errorf("want: true, got: false")
+			}
+		}
+		if ring.Push(100) {
+			t.Errorf("want: false, got: true")
+		}
+		if ring.Size() != 4 {
+			t.Errorf("want: 4, got: %v", ring.Size())
+		}
+		for i := 0; i < 4; i++ {
+			v, ok := ring.Pop()
+			if !ok {
+				t.Errorf("want: true, got: false")
+			}
+			got = append(got, v)
+		}
+	}
+
+	want := []int{0, 1, 2, 3, 10, 11, 12, 13, 20, 21, 22, 23}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want: %v, got: %v", want, got)
+	}
+	if want := []int{0, 0, 0, 0}; !reflect.DeepEqual(ring.elements, want) {
+		t.Errorf("want: %v, got: %v", want, ring.elements)
+	}
+}
+
+func TestMPMCRing(t *testing.T) {
+	ring := NewMPMCRing[int](4)
+	if ring.Capacity() != 4 {
+		t.Errorf("want: 4, got: %v", ring.Capacity())
+	}
+
+	if _, ok := ring.Pop(); ok {
+		t.Errorf("want: false, got: %v", ok)
+	}
+
+	got := []int{}
+	for round := 0; round < 3; round++ {
+		for i := 0; i < 4; i++ {
+			if !ring.Push(round*10 + i) {
+				t.Errorf("want: true, got: false")
+			}
+		}
+		if ring.Push(100) {
+			t.Errorf("want: false, got: true")
+		}
+		if ring.Size() != 4 {
+			t.Errorf("want: 4, got: %v", ring.Size())
+		}
+		for i := 0; i < 4; i++ {
+			v, ok := ring.Pop()
+			if !ok {
+				t.Errorf("want: true, got: false")
+			}
+			got = append(got, v)
+		}
+	}
+
+	want := []int{0, 1, 2, 3, 10, 11, 12, 13, 20, 21, 22, 23}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want: %v, got: %v", want, got)
+	}
+	if ring.Size() != 0 {
+		t.Errorf("want: 0, got: %v", ring.Size())
+	}
+}
+
+func TestSPSCRingStress(t *testing.T) {
+	ring := NewSPSCRing[int](16)
+	total := 100000
+
+	done := make(chan []int)
+	go func() {
+		got := make([]int, 0, total)
+		for len(got) < total {
+			if v, ok := ring.Pop(); ok {
+				got = append(got, v)
+			} else {
+				runtime.Gosched()
+			}
+		}
+		done <- got
+	}()
+
+	for i := 0; i < total; {
+		if ring.Push(i) {
+			i++
+		} else {
+			runtime.Gosched()
+		}
+	}
+
+	got := <-done
+	for i, v := range got {
+		if v != i {
+			t.Errorf("want: %v, got: %v", i, v)
+			return
+		}
+	}
+}
+
+func TestMPMCRingStress(t *testing.T) {
+	ring := NewMPMCRing[int](16)
+	producers, consumers, perProducer := 4, 4, 20000
+
+	var wg sync.WaitGroup
+	for p := 0; p < producers; p++ {
+		wg.Add(1)
+		go func(p int) {
+			defer wg.Done()
+			for i := 0; i < perProducer; {
+				if ring.Push(p*perProducer + i) {
+					i++
+				} else {
+					runtime.Gosched()
+				}
+			}
+		}(p)
+	}
+
+	results := make(chan []int, consumers)
+	var popped sync.WaitGroup
+	remain := make(chan struct{}, producers*perProducer)
+	for i := 0; i < producers*perProducer; i++ {
+		remain <- struct{}{}
+	}
+	for c := 0; c < consumers; c++ {
+		popped.Add(1)
+		go func() {
+			defer popped.Done()
+			got := []int{}
+			for range remain {
+				for {
+					if v, ok := ring.Pop(); ok {
+						got = append(got, v)
+						break
+					}
+					runtime.Gosched()
+				}
+				if len(remain) == 0 {
+					break
+				}
+			}
+			results <- got
+		}()
+	}
+
+	wg.Wait()
+	popped.Wait()
+	close(results)
+
+	seen := make([]bool, producers*perProducer)
+	count := 0
+	for got := range results {
+		// elements of the same producer are popped in push order by one consumer
+		last := map[int]int{}
+		for _, v := range got {
+			if seen[v] {
+				t.Errorf("duplicated: %v", v)
+			}
+			seen[v] = true
+			count++
+
+			p := v / perProducer
+			if prev, ok := last[p]; ok && prev >= v {
+				t.Errorf("want increasing, got: %v after %v", v, prev)
+			}
+			last[p] = v
+		}
+	}
+	if count != producers*perProducer {
+		t.Errorf("want: %v, got: %v", producers*perProducer, count)
+	}
+}
+
+func BenchmarkRingPushPop(b *testing.B) {
+	b.Run("mutex Ring", func(b *testing.B) {
+		ring := NewRing[int](1024)
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				ring.Push(1)
+				ring.PopFront()
+			}
+		})
+	})
+
+	b.Run("MPMCRing", func(b *testing.B) {
+		ring := NewMPMCRing[int](1024)
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				ring.Push(1)
+				ring.Pop()
+			}
+		})
+	})
+
+	b.Run("SPSCRing", func(b *testing.B) {
+		ring := NewSPSCRing[int](1024)
+		done := make(chan struct{})
+		go func() {
+			for i := 0; i < b.N; {
+				if _, ok := ring.Pop(); ok {
+					i++
+				} else {
+					runtime.Gosched()
+				}
+			}
+			close(done)
+		}()
+		for i := 0; i < b.N; {
+			if ring.Push(i) {
+				i++
+			} else {
+				runtime.Gosched()
+			}
+		}
+		<-done
+	})
+}
@@ -0,0 +1,170 @@
+package chper
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+)
+
+// LogTail keeps the last lines written to it, e.g. for "show the last 500 log lines" debug endpoints
+// it is an io.Writer built on Ring, writes are split into lines by '\n',
+// the incomplete last line is kept until its '\n' is written
+// it is also an http.Handler which writes the current contents as text/plain
+type LogTail struct {
+	ring *Ring[string]
+
+	// maxBytes bound the total bytes of lines and the incomplete last line, 0 means no bound
+	maxBytes     int
+	maxLineBytes int
+	bytes        int
+
+	partial []byte
+}
+
+type logTailOption struct {
+	maxBytes     int
+	maxLineBytes int
+}
+
+type logTailOptionFunc func(*logTailOption)
+
+// LogTailOptionMaxBytes bound the total bytes of lines (without '\n') including the incomplete last line,
+// old lines are removed first, a line longer than maxBytes keeps its last maxBytes bytes
+func LogTailOptionMaxBytes(maxBytes int) logTailOptionFunc {
+	return func(lo *logTailOption) {
+		lo.maxBytes = maxBytes
+	}
+}
+
+// LogTailOptionMaxLineBytes specify the max bytes of one line, default is 64KB
+// a longer line is split, so the incomplete last line never grows without bound
+func LogTailOptionMaxLineBytes(maxLineBytes int) logTailOptionFunc {
+	return func(lo *logTailOption) {
+		lo.maxLineBytes = maxLineBytes
+	}
+}
+
+// NewLogTail create a LogTail which keeps at most maxLines lines
+func NewLogTail(maxLines int, options ...logTailOptionFunc) *LogTail {
+	option := &logTailOption{maxLineBytes: 64 << 10}
+	for _, f := range options {
+		f(option)
+	}
+	if option.maxBytes < 0 {
+		panic("bad maxBytes")
+	}
+	if option.maxLineBytes < 1 {
+		panic("bad maxLineBytes")
+	}
+
+	return &LogTail{
+		ring:         NewRing[string](maxLines),
+		maxBytes:     option.maxBytes,
+		maxLineBytes: option.maxLineBytes,
+	}
+}
+
+// Write implements io.Writer, it never fails
+func (lt *LogTail) Write(p []byte) (int, error) {
+	lt.ring.lock.Lock()
+	defer lt.ring.lock.Unlock()
+
+	data := p
+	for len(data) > 0 {
+		i := bytes.IndexByte(data, '\n')
+		end := i
+		if i < 0 {
+			end = len(data)
+		}
+
+		// split the line if it is too long
+		if room := lt.maxLineBytes - len(lt.partial); end > room {
+			lt.partial = append(lt.partial, data[:room]...)
+			lt.flush()
+			data = data[room:]
+			continue
+		}
+
+		lt.partial = append(lt.partial, data[:end]...)
+		if i < 0 {
+			break
+		}
+		lt.flush()
+		data = data[i+1:]
+	}
+
+	if lt.maxBytes > 0 && len(lt.partial) > lt.maxBytes {
+		lt.partial = append(lt.partial[:0], lt.partial[len(lt.partial)-lt.maxBytes:]...)
+	}
+	lt.evict()
+
+	return len(p), nil
+}
+
+// flush push the partial line as a line, it must be called with ring.lock held
+func (lt *LogTail) flush() {
+	line := string(lt.partial)
+	lt.partial = lt.partial[:0]
+
+	if lt.maxBytes > 0 && len(line) > lt.maxBytes {
+		line = line[len(line)-lt.maxBytes:]
+	}
+
+	if evicted, ok := lt.ring.push(line); ok {
+		lt.bytes -= len(evicted)
+	}
+	lt.bytes += len(line)
+}
+
+// evict remove the oldest lines until lines and the partial line fit maxBytes, it must be called with ring.lock held
+func (lt *LogTail) evict() {
+	for lt.maxBytes > 0 && lt.bytes+len(lt.partial) > lt.maxBytes && lt.ring.size > 0 {
+		evicted, _ := lt.ring.popFront()
+		lt.bytes -= len(evicted)
+	}
+}
+
+// Lines return the kept lines without '\n', the incomplete last line is included
+func (lt *LogTail) Lines() []string {
+	lt.ring.lock.Lock()
+	defer lt.ring.lock.Unlock()
+
+	lines := make([]string, 0, lt.ring.size+1)
+	for i := 0; i < lt.ring.size; i++ {
+		lines = append(lines, lt.ring.at(i))
+	}
+	if len(lt.partial) > 0 {
+		lines = append(lines, string(lt.partial))
+	}
+
+	return lines
+}
+
+// Bytes return the total bytes of the kept lines, '\n' is not counted
+func (lt *LogTail) Bytes() int {
+	lt.ring.lock.Lock()
+	defer lt.ring.lock.Unlock()
+
+	return lt.bytes + len(lt.partial)
+}
+
+// WriteTo implements io.WriterTo, every line is followed by '\n' except the incomplete last line
+func (lt *LogTail) WriteTo(w io.Writer) (int64, error) {
+	lt.ring.lock.Lock()
+	buf := make([]byte, 0, lt.bytes+lt.ring.size+len(lt.partial))
+	for i := 0; i < lt.ring.size; i++ {
+		buf = append(buf, lt.ring.at(i)...)
+		buf = append(buf, '\n')
+	}
+	buf = append(buf, lt.partial...)
+	lt.ring.lock.Unlock()
+
+	n, err := w.Write(buf)
+	return int64(n), err
+}
+
+// ServeHTTP implements http.Handler, it writes the current contents as text/plain
+func (lt *LogTail) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	lt.WriteTo(w)
+}
@@ -0,0 +1,113 @@
+package chper
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestLogTail(t *testing.T) {
+	lt := NewLogTail(3)
+	var _ io.Writer = lt
+
+	if got := lt.Lines(); len(got) != 0 {
+		t.Errorf("want empty, got: %v", got)
+	}
+
+	for _, cas := range []struct {
+		write string
+		want  []string
+	}{
+		{write: "a\n", want: []string{"a"}},
+		{write: "b\nc", want: []string{"a", "b", "c"}},
+		{write: "c\n", want: []string{"a", "b", "cc"}},
+		{write: "\n", want: []string{"b", "cc", ""}},
+		{write: "d\ne\nf\ng", want: []string{"d", "e", "f", "g"}},
+	} {
+		n, err := lt.Write([]byte(cas.write))
+		if n != len(cas.write) || err != nil {
+			t.Errorf("want: %d nil, got: %d %v", len(cas.write), n, err)
+		}
+		if got := lt.Lines(); !reflect.DeepEqual(got, cas.want) {
+			t.Errorf("write: %q, want: %q, got: %q", cas.write, cas.want, got)
+		}
+	}
+
+	buf := &bytes.Buffer{}
+	n, err := lt.WriteTo(buf)
+	if want := "d\ne\nf\ng"; buf.String() != want || n != int64(len(want)) || err != nil {
+		t.Errorf("want: %q, got: %q %d %v", want, buf.String(), n, err)
+	}
+	if got := lt.Bytes(); got != 4 {
+		t.Errorf("want: 4, got: %v", got)
+	}
+}
+
+func TestLogTailMaxBytes(t *testing.T) {
+	lt := NewLogTail(100, LogTailOptionMaxBytes(10))
+
+	for _, cas := range []struct {
+		write string
+		want  []string
+	}{
+		{write: "aaaa\nbbbb\n", want: []string{"aaaa", "bbbb"}},
+		{write: "cccc\n", want: []string{"bbbb", "cccc"}},
+		{write: "0123456789abc\n", want: []string{"3456789abc"}},
+		{write: "d\n", want: []string{"d"}},
+		{write: "0123456789xyz", want: []string{"3456789xyz"}}, // the partial line is counted
+		{write: "\nab", want: []string{"ab"}},
+	} {
+		lt.Write([]byte(cas.write))
+		if got := lt.Lines(); !reflect.DeepEqual(got, cas.want) {
+			t.Errorf("write: %q, want: %q, got: %q", cas.write, cas.want, got)
+		}
+		if got := lt.Bytes(); got > 10 {
+			t.Errorf("write: %q, want at most 10 bytes, got: %d", cas.write, got)
+		}
+	}
+}
+
+func TestLogTailMaxLineBytes(t *testing.T) {
+	lt := NewLogTail(10, LogTailOptionMaxLineBytes(4))
+
+	lt.Write([]byte("ab"))
+	lt.Write([]byte("cdefghij"))
+	if want, got := []string{"abcd", "efgh", "ij"}, lt.Lines(); !reflect.DeepEqual(want, got) {
+		t.Errorf("want: %v, got: %v", want, got)
+	}
+	lt.Write([]byte("kl\nmnop\nq"))
+	if want, got := []string{"abcd", "efgh", "ijkl", "mnop", "q"}, lt.Lines(); !reflect.DeepEqual(want, got) {
+		t.Errorf("want: %v, got: %v", want, got)
+	}
+
+	// default line limit bounds a writer which never writes '\n'
+	lt = NewLogTail(2)
+	for i := 0; i < 100; i++ {
+		lt.Write(bytes.Repeat([]byte("x"), 1<<10))
+	}
+	if got := lt.Bytes(); got > 3*64<<10 {
+		t.Errorf("want at most %d, got: %d", 3*64<<10, got)
+	}
+}
+
+func TestLogTailHTTP(t *testing.T) {
+	lt := NewLogTail(2)
+	logger := log.New(lt, "", 0)
+	for i := 0; i < 5; i++ {
+		logger.Printf("line %d", i)
+	}
+
+	rec := httptest.NewRecorder()
+	lt.ServeHTTP(rec, httptest.NewRequest("GET", "/debug/log", nil))
+
+	if got := rec.Header().Get("Content-Type"); got != "text/plain; charset=utf-8" {
+		t.Errorf("want text/plain, got: %v", got)
+	}
+	if got, want := rec.Body.String(), fmt.Sprintf("line %d\nline %d\n", 3, 4); got != want {
+		t.Errorf("want: %q, got: %q", want, got)
+	}
+}
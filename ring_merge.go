@@ -0,0 +1,138 @@
+package chper
+
+import (
+	"container/heap"
+	"iter"
+)
+
+// MergeRings merge elements of rings into one timeline by less, e.g. a Ring per worker ordered by timestamp
+// every ring's elements must be sorted by less, equal elements keep the order of rings
+// filter has the same semantics as Ring.Elements, limit < 0 means all elements
+func MergeRings[V any](less func(a, b V) bool, limit int, filter func(V) bool, rings ...*Ring[V]) []V {
+	if limit == 0 {
+		return nil
+	}
+
+	chunk := mergeChunk
+	if limit > 0 && limit < chunk {
+		chunk = limit
+	}
+
+	var merged []V
+	for v := range mergeRings(less, filter, chunk, rings) {
+		merged = append(merged, v)
+		if len(merged) == limit {
+			break
+		}
+	}
+
+	return merged
+}
+
+// MergeRingsSeq is the iterator version of MergeRings, it is a streaming k-way merge by a heap
+// every ring is read lazily in small chunks, a ring is locked only while a chunk is read,
+// so breaking early costs only the elements read so far
+// if a ring is pushed or popped during the iteration, its logical indexes shift,
+// elements may be skipped or yielded twice
+func MergeRingsSeq[V any](less func(a, b V) bool, filter func(V) bool, rings ...*Ring[V]) iter.Seq[V] {
+	return mergeRings(less, filter, mergeChunk, rings)
+}
+
+// mergeChunk is the max elements read from a ring under its lock
+const mergeChunk = 64
+
+func mergeRings[V any](less func(a, b V) bool, filter func(V) bool, chunk int, rings []*Ring[V]) iter.Seq[V] {
+	return func(yield func(V) bool) {
+		h := &mergeHeap[V]{less: less}
+		for i, r := range rings {
+			c := &mergeCursor[V]{ring: r, order: i}
+			if c.fill(filter, chunk) {
+				h.cursors = append(h.cursors, c)
+			}
+		}
+		heap.Init(h)
+
+		for h.Len() > 0 {
+			c := h.cursors[0]
+			if !yield(c.head()) {
+				return
+			}
+
+			c.pos++
+			if c.fill(filter, chunk) {
+				heap.Fix(h, 0)
+			} else {
+				heap.Pop(h)
+			}
+		}
+	}
+}
+
+// mergeCursor is the read position of one ring
+type mergeCursor[V any] struct {
+	ring  *Ring[V]
+	order int
+
+	// next is the logical index of the next element to read from ring
+	next int
+	// buf[pos:] are the matched elements read but not yielded
+	buf []V
+	pos int
+}
+
+func (c *mergeCursor[V]) head() V {
+	return c.buf[c.pos]
+}
+
+// fill read the next chunk if buf is consumed, return false if the ring is exhausted
+func (c *mergeCursor[V]) fill(filter func(V) bool, chunk int) bool {
+	for c.pos == len(c.buf) {
+		var zero V
+		for i := range c.buf {
+			c.buf[i] = zero
+		}
+		c.buf, c.pos = c.buf[:0], 0
+
+		c.ring.lock.Lock()
+		size := c.ring.size
+		end := min(c.next+chunk, size)
+		for ; c.next < end; c.next++ {
+			if v := c.ring.at(c.next); filter == nil || filter(v) {
+				c.buf = append(c.buf, v)
+			}
+		}
+		c.ring.lock.Unlock()
+
+		if len(c.buf) == 0 && c.next >= size {
+			return false
+		}
+	}
+
+	return true
+}
+
+// mergeHeap is a min heap of cursors by their head elements
+type mergeHeap[V any] struct {
+	less    func(a, b V) bool
+	cursors []*mergeCursor[V]
+}
+
+func (h *mergeHeap[V]) Len() int { return len(h.cursors) }
+func (h *mergeHeap[V]) Less(i, j int) bool {
+	a, b := h.cursors[i], h.cursors[j]
+	if h.less(a.head(), b.head()) {
+		return true
+	}
+	if h.less(b.head(), a.head()) {
+		return false
+	}
+	return a.order < b.order
+}
+func (h *mergeHeap[V]) Swap(i, j int) { h.cursors[i], h.cursors[j] = h.cursors[j], h.cursors[i] }
+func (h *mergeHeap[V]) Push(x any)    { h.cursors = append(h.cursors, x.(*mergeCursor[V])) }
+func (h *mergeHeap[V]) Pop() any {
+	old := h.cursors
+	c := old[len(old)-1]
+	h.cursors = old[:len(old)-1]
+	return c
+}
@@ -0,0 +1,142 @@
+package chper
+
+import (
+	"reflect"
+	"testing"
+)
+
+type mergeEvent struct {
+	At     int
+	Worker string
+}
+
+func newMergeTestRings() []*Ring[mergeEvent] {
+	r1, r2, r3 := NewRing[mergeEvent](3), NewRing[mergeEvent](3), NewRing[mergeEvent](3)
+	for _, at := range []int{1, 4, 7, 9} { // 1 is evicted
+		r1.Push(mergeEvent{At: at, Worker: "w1"})
+	}
+	for _, at := range []int{2, 4, 8} {
+		r2.Push(mergeEvent{At: at, Worker: "w2"})
+	}
+	return []*Ring[mergeEvent]{r1, r2, r3}
+}
+
+func TestMergeRings(t *testing.T) {
+	rings := newMergeTestRings()
+	less := func(a, b mergeEvent) bool { return a.At < b.At }
+
+	all := []mergeEvent{{2, "w2"}, {4, "w1"}, {4, "w2"}, {7, "w1"}, {8, "w2"}, {9, "w1"}}
+	for _, cas := range []struct {
+		name   string
+		limit  int
+		filter func(mergeEvent) bool
+		want   []mergeEvent
+	}{
+		{name: "all", limit: -1, want: all},
+		{name: "limit", limit: 3, want: all[:3]},
+		{name: "large limit", limit: 100, want: all},
+		{name: "zero limit", limit: 0, want: nil},
+		{name: "filter", limit: -1, filter: func(e mergeEvent) bool { return e.At%2 == 0 },
+			want: []mergeEvent{{2, "w2"}, {4, "w1"}, {4, "w2"}, {8, "w2"}}},
+		{name: "filter and limit", limit: 2, filter: func(e mergeEvent) bool { return e.Worker == "w1" },
+			want: []mergeEvent{{4, "w1"}, {7, "w1"}}},
+	} {
+		if got := MergeRings(less, cas.limit, cas.filter, rings...); !reflect.DeepEqual(got, cas.want) {
+			t.Errorf("%s, want: %v, got: %v", cas.name, cas.want, got)
+		}
+	}
+
+	if got := MergeRings[int](func(a, b int) bool { return a < b }, -1, nil); got != nil {
+		t.Errorf("want nil, got: %v", got)
+	}
+}
+
+func TestMergeRingsLimitCopy(t *testing.T) {
+	r1, r2 := NewRing[int](100), NewRing[int](100)
+	for i := 0; i < 100; i++ {
+		r1.Push(2 * i)
+		r2.Push(2*i + 1)
+	}
+
+	visited := 0
+	filter := func(v int) bool {
+		visited++
+		return true
+	}
+	got := MergeRings(func(a, b int) bool { return a < b }, 3, filter, r1, r2)
+	if want := []int{0, 1, 2}; !reflect.DeepEqual(want, got) {
+		t.Errorf("want: %v, got: %v", want, got)
+	}
+	if visited != 6 {
+		t.Errorf("want 3 elements visited per ring, got: %v", visited)
+	}
+}
+
+func TestMergeRingsSeqLazy(t *testing.T) {
+	r1, r2 := NewRing[int](1000), NewRing[int](1000)
+	for i := 0; i < 1000; i++ {
+		r1.Push(2 * i)
+		r2.Push(2*i + 1)
+	}
+
+	visited := 0
+	filter := func(v int) bool {
+		visited++
+		return true
+	}
+	var got []int
+	for v := range MergeRingsSeq(func(a, b int) bool { return a < b }, filter, r1, r2) {
+		got = append(got, v)
+		if len(got) == 200 {
+			break
+		}
+	}
+	want := make([]int, 200)
+	for i := range want {
+		want[i] = i
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want: 0..199, got: %v", got)
+	}
+	if visited > 200+2*mergeChunk {
+		t.Errorf("want at most %d elements visited, got: %v", 200+2*mergeChunk, visited)
+	}
+}
+
+func TestMergeRingsSeqConcurrentPush(t *testing.T) {
+	r1, r2 := NewRing[int](100), NewRing[int](100)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 1000; i++ {
+			r1.Push(i)
+			r2.Push(i)
+		}
+	}()
+
+	for i := 0; i < 20; i++ {
+		for range MergeRingsSeq(func(a, b int) bool { return a < b }, nil, r1, r2) {
+		}
+	}
+	<-done
+
+	if got := len(MergeRings(func(a, b int) bool { return a < b }, -1, nil, r1, r2)); got != 200 {
+		t.Errorf("want: 200, got: %v", got)
+	}
+}
+
+func TestMergeRingsSeq(t *testing.T) {
+	rings := newMergeTestRings()
+
+	var got []int
+	for e := range MergeRingsSeq(func(a, b mergeEvent) bool { return a.At < b.At }, nil, rings...) {
+		if e.At > 7 {
+			break
+		}
+		got = append(got, e.At)
+	}
+	if want := []int{2, 4, 4, 7}; !reflect.DeepEqual(want, got) {
+		t.Errorf("want: %v, got: %v", want, got)
+	}
+}
@@ -0,0 +1,254 @@
+package chper
+
+import (
+	"math"
+)
+
+// NumericRing is a Ring of Numeric values with sliding-window aggregates
+// Sum, Mean and Count are O(1), Min and Max are amortized O(1) by monotonic deques,
+// Percentile sorts a copy of elements on demand
+type NumericRing[V Numeric] struct {
+	ring *Ring[V]
+
+	// sum is accumulated in a wider type, so small integer types do not overflow
+	sum numericSum[V]
+	// seq is the push index of the next element
+	seq uint64
+	// minDeque values are increasing, maxDeque values are decreasing
+	minDeque []numericEntry[V]
+	maxDeque []numericEntry[V]
+}
+
+type numericEntry[V Numeric] struct {
+	seq   uint64
+	value V
+}
+
+func NewNumericRing[V Numeric](capacity int) *NumericRing[V] {
+	return &NumericRing[V]{
+		ring: NewRing[V](capacity),
+		sum:  newNumericSum[V](),
+	}
+}
+
+type numericKind int
+
+const (
+	numericSigned numericKind = iota
+	numericUnsigned
+	numericFloat
+)
+
+// numericSum is a sum of V, signed integers are added in int64, unsigned integers in uint64
+// and floats in float64 by Neumaier's compensated summation
+// non-finite floats are counted instead of added, so the sum recovers after they are removed
+type numericSum[V Numeric] struct {
+	kind numericKind
+
+	i int64
+	u uint64
+
+	f, compensation        float64
+	nans, posInfs, negInfs int
+}
+
+func newNumericSum[V Numeric]() numericSum[V] {
+	var zero V
+	switch any(zero).(type) {
+	case uint, uint8, uint16, uint32, uint64:
+		return numericSum[V]{kind: numericUnsigned}
+	case float32, float64:
+		return numericSum[V]{kind: numericFloat}
+	default:
+		return numericSum[V]{kind: numericSigned}
+	}
+}
+
+func (s *numericSum[V]) add(v V) {
+	switch s.kind {
+	case numericUnsigned:
+		s.u += uint64(v)
+	case numericFloat:
+		s.addFloat(float64(v), 1)
+	default:
+		s.i += int64(v)
+	}
+}
+
+func (s *numericSum[V]) sub(v V) {
+	switch s.kind {
+	case numericUnsigned:
+		s.u -= uint64(v)
+	case numericFloat:
+		s.addFloat(-float64(v), -1)
+	default:
+		s.i -= int64(v)
+	}
+}
+
+// addFloat add f, delta is 1 for adding an element and -1 for removing one
+func (s *numericSum[V]) addFloat(f float64, delta int) {
+	switch {
+	case math.IsNaN(f):
+		s.nans += delta
+		return
+	case math.IsInf(f, 0):
+		// f is negated when removing
+		if (f > 0) == (delta > 0) {
+			s.posInfs += delta
+		} else {
+			s.negInfs += delta
+		}
+		return
+	}
+
+	t := s.f + f
+	if math.Abs(s.f) >= math.Abs(f) {
+		s.compensation += (s.f - t) + f
+	} else {
+		s.compensation += (f - t) + s.f
+	}
+	s.f = t
+}
+
+func (s *numericSum[V]) value() float64 {
+	switch s.kind {
+	case numericUnsigned:
+		return float64(s.u)
+	case numericFloat:
+		switch {
+		case s.nans > 0 || (s.posInfs > 0 && s.negInfs > 0):
+			return math.NaN()
+		case s.posInfs > 0:
+			return math.Inf(1)
+		case s.negInfs > 0:
+			return math.Inf(-1)
+		}
+		return s.f + s.compensation
+	default:
+		return float64(s.i)
+	}
+}
+
+// Push append one element, the oldest element is removed if NumericRing is filled
+func (nr *NumericRing[V]) Push(data V) {
+	nr.ring.lock.Lock()
+	defer nr.ring.lock.Unlock()
+
+	if nr.ring.size == nr.ring.capacity {
+		oldest := nr.seq - uint64(nr.ring.size)
+		nr.sum.sub(nr.ring.at(0))
+		if nr.minDeque[0].seq == oldest {
+			nr.minDeque = nr.minDeque[1:]
+		}
+		if nr.maxDeque[0].seq == oldest {
+			nr.maxDeque = nr.maxDeque[1:]
+		}
+	}
+
+	nr.ring.push(data)
+	nr.sum.add(data)
+
+	entry := numericEntry[V]{seq: nr.seq, value: data}
+	nr.seq++
+
+	for len(nr.minDeque) > 0 && nr.minDeque[len(nr.minDeque)-1].value > data {
+		nr.minDeque = nr.minDeque[:len(nr.minDeque)-1]
+	}
+	nr.minDeque = append(nr.minDeque, entry)
+
+	for len(nr.maxDeque) > 0 && nr.maxDeque[len(nr.maxDeque)-1].value < data {
+		nr.maxDeque = nr.maxDeque[:len(nr.maxDeque)-1]
+	}
+	nr.maxDeque = append(nr.maxDeque, entry)
+}
+
+// Sum return the sum of elements, it is float64 because the sum may overflow V
+func (nr *NumericRing[V]) Sum() float64 {
+	nr.ring.lock.Lock()
+	defer nr.ring.lock.Unlock()
+
+	return nr.sum.value()
+}
+
+// Mean return the average of elements, 0 if there is no element
+func (nr *NumericRing[V]) Mean() float64 {
+	nr.ring.lock.Lock()
+	defer nr.ring.lock.Unlock()
+
+	if nr.ring.size == 0 {
+		return 0
+	}
+
+	return nr.sum.value() / float64(nr.ring.size)
+}
+
+// Count return elements count
+func (nr *NumericRing[V]) Count() int {
+	return nr.ring.Size()
+}
+
+// Min return the minimum element and exist
+func (nr *NumericRing[V]) Min() (v V, ok bool) {
+	nr.ring.lock.Lock()
+	defer nr.ring.lock.Unlock()
+
+	if nr.ring.size == 0 {
+		return
+	}
+
+	return nr.minDeque[0].value, true
+}
+
+// Max return the maximum element and exist
+func (nr *NumericRing[V]) Max() (v V, ok bool) {
+	nr.ring.lock.Lock()
+	defer nr.ring.lock.Unlock()
+
+	if nr.ring.size == 0 {
+		return
+	}
+
+	return nr.maxDeque[0].value, true
+}
+
+// Percentile return the p-th percentile of elements, p is in [0, 100]
+// it interpolates linearly between the closest ranks
+func (nr *NumericRing[V]) Percentile(p float64) (float64, bool) {
+	if p < 0 || p > 100 || math.IsNaN(p) {
+		panic("bad percentile")
+	}
+
+	values := nr.ring.Elements(nil)
+	if len(values) == 0 {
+		return 0, false
+	}
+	SliceSort(values)
+
+	rank := p / 100 * float64(len(values)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	fraction := rank - float64(lower)
+
+	return float64(values[lower]) + (float64(values[upper])-float64(values[lower]))*fraction, true
+}
+
+// Elements return matched elements, sorted by push index
+func (nr *NumericRing[V]) Elements(filter func(V) bool) []V {
+	return nr.ring.Elements(filter)
+}
+
+// First return first element and exist
+func (nr *NumericRing[V]) First() (V, bool) {
+	return nr.ring.First()
+}
+
+// Last return last element and exist
+func (nr *NumericRing[V]) Last() (V, bool) {
+	return nr.ring.Last()
+}
+
+// Size return elements count
+func (nr *NumericRing[V]) Size() int {
+	return nr.ring.Size()
+}
@@ -0,0 +1,220 @@
+package chper
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestNumericRing(t *testing.T) {
+	ring := NewNumericRing[int](3)
+
+	if _, ok := ring.Min(); ok {
+		t.Errorf("want: false, got: %v", ok)
+	}
+	if _, ok := ring.Max(); ok {
+		t.Errorf("want: false, got: %v", ok)
+	}
+	if _, ok := ring.Percentile(50); ok {
+		t.Errorf("want: false, got: %v", ok)
+	}
+	if got := ring.Mean(); got != 0 {
+		t.Errorf("want: 0, got: %v", got)
+	}
+
+	for _, cas := range []struct {
+		push     int
+		wantSum  float64
+		wantMean float64
+		wantMin  int
+		wantMax  int
+	}{
+		{push: 5, wantSum: 5, wantMean: 5, wantMin: 5, wantMax: 5},
+		{push: 1, wantSum: 6, wantMean: 3, wantMin: 1, wantMax: 5},
+		{push: 3, wantSum: 9, wantMean: 3, wantMin: 1, wantMax: 5},
+		{push: 3, wantSum: 7, wantMean: 7.0 / 3, wantMin: 1, wantMax: 3},   // 5 removed
+		{push: 4, wantSum: 10, wantMean: 10.0 / 3, wantMin: 3, wantMax: 4}, // 1 removed
+		{push: 2, wantSum: 9, wantMean: 3, wantMin: 2, wantMax: 4},         // 3 removed
+		{push: 9, wantSum: 15, wantMean: 5, wantMin: 2, wantMax: 9},        // 3 removed
+	} {
+		ring.Push(cas.push)
+		if got := ring.Sum(); got != cas.wantSum {
+			t.Errorf("push: %d, sum want: %v, got: %v", cas.push, cas.wantSum, got)
+		}
+		if got := ring.Mean(); got != cas.wantMean {
+			t.Errorf("push: %d, mean want: %v, got: %v", cas.push, cas.wantMean, got)
+		}
+		if got, _ := ring.Min(); got != cas.wantMin {
+			t.Errorf("push: %d, min want: %v, got: %v", cas.push, cas.wantMin, got)
+		}
+		if got, _ := ring.Max(); got != cas.wantMax {
+			t.Errorf("push: %d, max want: %v, got: %v", cas.push, cas.wantMax, got)
+		}
+	}
+
+	if got := ring.Count(); got != 3 {
+		t.Errorf("want: 3, got: %v", got)
+	}
+	if first, _ := ring.First(); first != 4 {
+		t.Errorf("want: 4, got: %v", first)
+	}
+	if last, _ := ring.Last(); last != 9 {
+		t.Errorf("want: 9, got: %v", last)
+	}
+}
+
+func TestNumericRingPercentile(t *testing.T) {
+	ring := NewNumericRing[float64](5)
+	for _, v := range []float64{100, 1, 5, 3, 2, 4} {
+		ring.Push(v)
+	}
+	// elements: 1 5 3 2 4
+
+	for _, cas := range []struct {
+		p    float64
+		want float64
+	}{
+		{p: 0, want: 1},
+		{p: 25, want: 2},
+		{p: 50, want: 3},
+		{p: 90, want: 4.6},
+		{p: 100, want: 5},
+	} {
+		got, ok := ring.Percentile(cas.p)
+		if !ok || got < cas.want-1e-9 || got > cas.want+1e-9 {
+			t.Errorf("p%v, want: %v, got: %v", cas.p, cas.want, got)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("want panic")
+		}
+	}()
+	ring.Percentile(101)
+}
+
+func TestNumericRingRandom(t *testing.T) {
+	ring := NewNumericRing[int64](50)
+	random := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 2000; i++ {
+		ring.Push(random.Int63n(1000) - 500)
+
+		elements := ring.Elements(nil)
+		sum, min, max := int64(0), elements[0], elements[0]
+		for _, v := range elements {
+			sum += v
+			if v < min {
+				min = v
+			}
+			if v > max {
+				max = v
+			}
+		}
+
+		if got := ring.Sum(); got != float64(sum) {
+			t.Errorf("sum want: %v, got: %v", sum, got)
+		}
+		if got, _ := ring.Min(); got != min {
+			t.Errorf("min want: %v, got: %v", min, got)
+		}
+		if got, _ := ring.Max(); got != max {
+			t.Errorf("max want: %v, got: %v", max, got)
+		}
+	}
+}
+
+func TestNumericRingNarrowType(t *testing.T) {
+	u8 := NewNumericRing[uint8](3)
+	u8.Push(200)
+	u8.Push(200)
+	if got := u8.Sum(); got != 400 {
+		t.Errorf("want: 400, got: %v", got)
+	}
+	if got := u8.Mean(); got != 200 {
+		t.Errorf("want: 200, got: %v", got)
+	}
+	u8.Push(1)
+	u8.Push(2) // 200 removed
+	if got := u8.Sum(); got != 203 {
+		t.Errorf("want: 203, got: %v", got)
+	}
+
+	i8 := NewNumericRing[int8](2)
+	i8.Push(100)
+	i8.Push(100)
+	if got := i8.Mean(); got != 100 {
+		t.Errorf("want: 100, got: %v", got)
+	}
+	i8.Push(-128) // 100 removed
+	if got := i8.Sum(); got != -28 {
+		t.Errorf("want: -28, got: %v", got)
+	}
+	if got := i8.Mean(); got != -14 {
+		t.Errorf("want: -14, got: %v", got)
+	}
+
+	f32 := NewNumericRing[float32](2)
+	f32.Push(1.5)
+	f32.Push(2.5)
+	if got := f32.Sum(); got != 4 {
+		t.Errorf("want: 4, got: %v", got)
+	}
+}
+
+func TestNumericRingFloatSum(t *testing.T) {
+	ring := NewNumericRing[float64](2)
+	for _, v := range []float64{1e17, 1, 1, 1} {
+		ring.Push(v)
+	}
+	if got := ring.Sum(); got != 2 {
+		t.Errorf("want: 2, got: %v", got)
+	}
+	if got := ring.Mean(); got != 1 {
+		t.Errorf("want: 1, got: %v", got)
+	}
+
+	ring = NewNumericRing[float64](1)
+	for _, cas := range []struct {
+		push float64
+		want float64
+	}{
+		{push: math.Inf(1), want: math.Inf(1)},
+		{push: 1, want: 1},
+		{push: math.Inf(-1), want: math.Inf(-1)},
+		{push: math.NaN(), want: math.NaN()},
+		{push: 2, want: 2},
+	} {
+		ring.Push(cas.push)
+		got := ring.Sum()
+		if got != cas.want && !(math.IsNaN(got) && math.IsNaN(cas.want)) {
+			t.Errorf("push: %v, want: %v, got: %v", cas.push, cas.want, got)
+		}
+	}
+	if got := ring.Mean(); got != 2 {
+		t.Errorf("want: 2, got: %v", got)
+	}
+
+	ring = NewNumericRing[float64](2)
+	ring.Push(math.Inf(1))
+	ring.Push(math.Inf(-1))
+	if got := ring.Sum(); !math.IsNaN(got) {
+		t.Errorf("want NaN, got: %v", got)
+	}
+	ring.Push(3)
+	ring.Push(4)
+	if got := ring.Sum(); got != 7 {
+		t.Errorf("want: 7, got: %v", got)
+	}
+}
+
+func BenchmarkNumericRing(b *testing.B) {
+	ring := NewNumericRing[float64](1000)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		ring.Push(float64(i % 97))
+		ring.Mean()
+		ring.Max()
+	}
+}
@@ -0,0 +1,395 @@
+package chper
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"sync"
+)
+
+var (
+	ErrRecordTooLarge    = errors.New("record too large")
+	ErrRingFileCorrupted = errors.New("ring file corrupted")
+	ErrRingFileMismatch  = errors.New("ring file mismatch")
+)
+
+// Codec convert V to bytes and back
+type Codec[V any] interface {
+	Marshal(v V) ([]byte, error)
+	Unmarshal(data []byte) (V, error)
+}
+
+// JSONCodec is a Codec using encoding/json
+type JSONCodec[V any] struct{}
+
+func (JSONCodec[V]) Marshal(v V) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (JSONCodec[V]) Unmarshal(data []byte) (v V, err error) {
+	err = json.Unmarshal(data, &v)
+	return
+}
+
+// GobCodec is a Codec using encoding/gob
+type GobCodec[V any] struct{}
+
+func (GobCodec[V]) Marshal(v V) ([]byte, error) {
+	buf := bytes.Buffer{}
+	err := gob.NewEncoder(&buf).Encode(v)
+	return buf.Bytes(), err
+}
+
+func (GobCodec[V]) Unmarshal(data []byte) (v V, err error) {
+	err = gob.NewDecoder(bytes.NewReader(data)).Decode(&v)
+	return
+}
+
+/*
+PersistentRing file layout, all integers are little endian:
+
+	header A	[0, 64)
+	header B	[64, 128)
+	slot 0		[128, 128+slotSize)
+	...
+	slot N-1
+
+header: magic(8) capacity(4) slotSize(4) generation(8) begin(8) next(8) crc32(4)
+
+	two headers are written alternately, the valid one with the larger generation wins,
+	so a torn header write never loses the previous header
+	begin and next are record sequences, records in [begin, next) are in the ring
+
+slot: length(4) crc32(4) sequence(8) payload(length)
+
+	record of sequence s is in slot s%capacity, crc32 covers sequence and payload
+	a record is written before the header, on open records after next are scanned,
+	so the ring recovers to the last fully written record
+*/
+const (
+	ringFileMagic      = "CHPRRING"
+	ringFileHeaderSize = 64
+	ringFileDataOffset = 2 * ringFileHeaderSize
+	ringFileRecordHead = 16
+)
+
+type ringFileHeader struct {
+	capacity   uint32
+	slotSize   uint32
+	generation uint64
+	begin      uint64
+	next       uint64
+}
+
+func (h *ringFileHeader) encode() []byte {
+	bs := make([]byte, ringFileHeaderSize)
+	copy(bs, ringFileMagic)
+	binary.LittleEndian.PutUint32(bs[8:], h.capacity)
+	binary.LittleEndian.PutUint32(bs[12:], h.slotSize)
+	binary.LittleEndian.PutUint64(bs[16:], h.generation)
+	binary.LittleEndian.PutUint64(bs[24:], h.begin)
+	binary.LittleEndian.PutUint64(bs[32:], h.next)
+	binary.LittleEndian.PutUint32(bs[40:], crc32.ChecksumIEEE(bs[:40]))
+
+	return bs
+}
+
+func decodeRingFileHeader(bs []byte) (h ringFileHeader, ok bool) {
+	if string(bs[:8]) != ringFileMagic || binary.LittleEndian.Uint32(bs[40:]) != crc32.ChecksumIEEE(bs[:40]) {
+		return
+	}
+
+	h.capacity = binary.LittleEndian.Uint32(bs[8:])
+	h.slotSize = binary.LittleEndian.Uint32(bs[12:])
+	h.generation = binary.LittleEndian.Uint64(bs[16:])
+	h.begin = binary.LittleEndian.Uint64(bs[24:])
+	h.next = binary.LittleEndian.Uint64(bs[32:])
+
+	return h, true
+}
+
+// ringFile is the part of *os.File which PersistentRing uses
+type ringFile interface {
+	io.ReaderAt
+	io.WriterAt
+	Stat() (os.FileInfo, error)
+	Truncate(size int64) error
+	Sync() error
+	Close() error
+}
+
+// PersistentRing is a Ring backed by a file, its contents survive restart
+// reads are served by an in-memory Ring, Push write the record and the header to the file
+type PersistentRing[V any] struct {
+	ring   *Ring[V]
+	codec  Codec[V]
+	file   ringFile
+	header ringFileHeader
+
+	syncEveryPush bool
+
+	// lock serializes file writes, ring has its own lock for reads
+	lock sync.Mutex
+}
+
+type persistentRingOption struct {
+	syncEveryPush bool
+}
+
+type persistentRingOptionFunc func(*persistentRingOption)
+
+// PersistentRingOptionSync specify whether fsync after every Push, default is false
+func PersistentRingOptionSync(syncEveryPush bool) persistentRingOptionFunc {
+	return func(po *persistentRingOption) {
+		po.syncEveryPush = syncEveryPush
+	}
+}
+
+// OpenPersistentRing open or create the ring file
+// slotSize is the max encoded size of one element, plus 16 bytes record head
+// an existing file must have the same capacity and slotSize
+func OpenPersistentRing[V any](path string, capacity, slotSize int, codec Codec[V],
+	options ...persistentRingOptionFunc) (*PersistentRing[V], error) {
+
+	if capacity < 1 {
+		panic("bad capacity")
+	}
+	if slotSize <= ringFileRecordHead {
+		panic("bad slotSize")
+	}
+
+	option := &persistentRingOption{}
+	for _, f := range options {
+		f(option)
+	}
+
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	pr := &PersistentRing[V]{
+		ring:  NewRing[V](capacity),
+		codec: codec,
+		file:  file,
+		header: ringFileHeader{
+			capacity: uint32(capacity),
+			slotSize: uint32(slotSize),
+		},
+		syncEveryPush: option.syncEveryPush,
+	}
+
+	if err := pr.recover(); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return pr, nil
+}
+
+func (pr *PersistentRing[V]) recover() error {
+	info, err := pr.file.Stat()
+	if err != nil {
+		return err
+	}
+
+	size := int64(ringFileDataOffset) + int64(pr.header.capacity)*int64(pr.header.slotSize)
+	if info.Size() == 0 {
+		if err := pr.file.Truncate(size); err != nil {
+			return err
+		}
+		return pr.writeHeader()
+	}
+
+	headers := make([]byte, ringFileDataOffset)
+	if _, err := pr.file.ReadAt(headers, 0); err != nil {
+		return fmt.Errorf("%w, read header fail, err : %v", ErrRingFileCorrupted, err)
+	}
+	a, okA := decodeRingFileHeader(headers[:ringFileHeaderSize])
+	b, okB := decodeRingFileHeader(headers[ringFileHeaderSize:])
+	if !okA && !okB {
+		return fmt.Errorf("%w, no valid header", ErrRingFileCorrupted)
+	}
+	header := a
+	if !okA || (okB && b.generation > a.generation) {
+		header = b
+	}
+
+	if header.capacity != pr.header.capacity || header.slotSize != pr.header.slotSize {
+		return fmt.Errorf("%w, file capacity: %d, slotSize: %d", ErrRingFileMismatch, header.capacity, header.slotSize)
+	}
+	if info.Size() < size {
+		if err := pr.file.Truncate(size); err != nil {
+			return err
+		}
+	}
+
+	// records written after the last header
+	for {
+		if _, ok, err := pr.readRecord(header.next); err != nil {
+			return err
+		} else if !ok {
+			break
+		}
+		header.next++
+	}
+	if header.next-header.begin > uint64(header.capacity) {
+		header.begin = header.next - uint64(header.capacity)
+	}
+
+	// skip records which are invalid, e.g. the oldest slot is being overwritten
+	for seq := header.begin; seq < header.next; seq++ {
+		v, ok, err := pr.readRecord(seq)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			if pr.ring.size == 0 {
+				header.begin = seq + 1
+				continue
+			}
+			header.next = seq
+			break
+		}
+		pr.ring.push(v)
+	}
+
+	pr.header = header
+	return nil
+}
+
+// readRecord return ok = false if the record is not fully written
+func (pr *PersistentRing[V]) readRecord(seq uint64) (v V, ok bool, err error) {
+	bs := make([]byte, pr.header.slotSize)
+	if _, err = pr.file.ReadAt(bs, pr.slotOffset(seq)); err != nil && err != io.EOF {
+		return
+	}
+	err = nil
+
+	length := binary.LittleEndian.Uint32(bs)
+	if length > pr.header.slotSize-ringFileRecordHead || binary.LittleEndian.Uint64(bs[8:]) != seq ||
+		binary.LittleEndian.Uint32(bs[4:]) != crc32.ChecksumIEEE(bs[8:ringFileRecordHead+length]) {
+		return
+	}
+
+	v, err = pr.codec.Unmarshal(bs[ringFileRecordHead : ringFileRecordHead+length])
+	if err != nil {
+		err = fmt.Errorf("%w, unmarshal record %d fail, err : %v", ErrRingFileCorrupted, seq, err)
+		return
+	}
+
+	return v, true, nil
+}
+
+func (pr *PersistentRing[V]) slotOffset(seq uint64) int64 {
+	return int64(ringFileDataOffset) + int64(seq%uint64(pr.header.capacity))*int64(pr.header.slotSize)
+}
+
+func (pr *PersistentRing[V]) writeHeader() error {
+	pr.header.generation++
+	offset := int64(pr.header.generation%2) * ringFileHeaderSize
+	_, err := pr.file.WriteAt(pr.header.encode(), offset)
+
+	return err
+}
+
+// Push append one element to the file and the Ring
+// the oldest element is dropped before its slot is overwritten, so it is dropped even if the write fails
+// the record is recovered on open once it is written, so if the following header write or fsync fails,
+// the element is still pushed and the error is returned
+func (pr *PersistentRing[V]) Push(data V) error {
+	payload, err := pr.codec.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if len(payload) > int(pr.header.slotSize)-ringFileRecordHead {
+		return fmt.Errorf("%w, size: %d, slotSize: %d", ErrRecordTooLarge, len(payload)+ringFileRecordHead, pr.header.slotSize)
+	}
+
+	pr.lock.Lock()
+	defer pr.lock.Unlock()
+
+	seq := pr.header.next
+	record := make([]byte, ringFileRecordHead+len(payload))
+	binary.LittleEndian.PutUint32(record, uint32(len(payload)))
+	binary.LittleEndian.PutUint64(record[8:], seq)
+	copy(record[ringFileRecordHead:], payload)
+	binary.LittleEndian.PutUint32(record[4:], crc32.ChecksumIEEE(record[8:]))
+
+	// the slot holds the oldest record if the ring is filled, a torn write destroys it,
+	// so it is dropped before the write
+	if pr.header.next-pr.header.begin == uint64(pr.header.capacity) {
+		pr.header.begin++
+		pr.ring.PopFront()
+	}
+
+	if _, err := pr.file.WriteAt(record, pr.slotOffset(seq)); err != nil {
+		return err
+	}
+
+	pr.header.next++
+	pr.ring.Push(data)
+
+	if pr.syncEveryPush {
+		if err := pr.file.Sync(); err != nil {
+			return err
+		}
+	}
+	if err := pr.writeHeader(); err != nil {
+		return err
+	}
+	if pr.syncEveryPush {
+		if err := pr.file.Sync(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Elements return matched elements, sorted by push index
+func (pr *PersistentRing[V]) Elements(filter func(V) bool) []V {
+	return pr.ring.Elements(filter)
+}
+
+// First return first element and exist
+func (pr *PersistentRing[V]) First() (V, bool) {
+	return pr.ring.First()
+}
+
+// Last return last element and exist
+func (pr *PersistentRing[V]) Last() (V, bool) {
+	return pr.ring.Last()
+}
+
+// Size return ring's elements count
+func (pr *PersistentRing[V]) Size() int {
+	return pr.ring.Size()
+}
+
+// Sync commit the file to stable storage
+func (pr *PersistentRing[V]) Sync() error {
+	pr.lock.Lock()
+	defer pr.lock.Unlock()
+
+	return pr.file.Sync()
+}
+
+// Close sync and close the file
+func (pr *PersistentRing[V]) Close() error {
+	pr.lock.Lock()
+	defer pr.lock.Unlock()
+
+	if err := pr.file.Sync(); err != nil {
+		pr.file.Close()
+		return err
+	}
+
+	return pr.file.Close()
+}
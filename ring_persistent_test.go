@@ -0,0 +1,348 @@
+package chper
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPersistentRing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ring")
+
+	pr, err := OpenPersistentRing[string](path, 3, 64, JSONCodec[string]{})
+	if err != nil {
+		t.Errorf("want nil, got: %v", err)
+		return
+	}
+	if _, ok := pr.First(); ok {
+		t.Errorf("want: false, got: %v", ok)
+	}
+
+	for _, s := range []string{"a", "b", "c", "d"} {
+		if err := pr.Push(s); err != nil {
+			t.Errorf("want nil, got: %v", err)
+		}
+	}
+	if err := pr.Push(strings.Repeat("x", 64)); !errors.Is(err, ErrRecordTooLarge) {
+		t.Errorf("want: %v, got: %v", ErrRecordTooLarge, err)
+	}
+	want := []string{"b", "c", "d"}
+	if got := pr.Elements(nil); !reflect.DeepEqual(got, want) {
+		t.Errorf("want: %v, got: %v", want, got)
+	}
+	if err := pr.Close(); err != nil {
+		t.Errorf("want nil, got: %v", err)
+	}
+
+	// reopen
+	pr, err = OpenPersistentRing[string](path, 3, 64, JSONCodec[string]{})
+	if err != nil {
+		t.Errorf("want nil, got: %v", err)
+		return
+	}
+	if got := pr.Elements(nil); !reflect.DeepEqual(got, want) {
+		t.Errorf("want: %v, got: %v", want, got)
+	}
+	pr.Push("e")
+	want = []string{"c", "d", "e"}
+	if got := pr.Elements(nil); !reflect.DeepEqual(got, want) {
+		t.Errorf("want: %v, got: %v", want, got)
+	}
+	if first, _ := pr.First(); first != "c" {
+		t.Errorf("want: c, got: %v", first)
+	}
+	if last, _ := pr.Last(); last != "e" {
+		t.Errorf("want: e, got: %v", last)
+	}
+	if pr.Size() != 3 {
+		t.Errorf("want: 3, got: %v", pr.Size())
+	}
+	pr.Close()
+
+	// mismatch
+	if _, err := OpenPersistentRing[string](path, 4, 64, JSONCodec[string]{}); !errors.Is(err, ErrRingFileMismatch) {
+		t.Errorf("want: %v, got: %v", ErrRingFileMismatch, err)
+	}
+}
+
+func TestPersistentRingGob(t *testing.T) {
+	type event struct {
+		ID   int
+		Name string
+	}
+	path := filepath.Join(t.TempDir(), "ring")
+
+	pr, err := OpenPersistentRing[event](path, 2, 128, GobCodec[event]{}, PersistentRingOptionSync(true))
+	if err != nil {
+		t.Errorf("want nil, got: %v", err)
+		return
+	}
+	pr.Push(event{1, "a"})
+	pr.Push(event{2, "b"})
+	pr.Sync()
+	pr.Close()
+
+	pr, err = OpenPersistentRing[event](path, 2, 128, GobCodec[event]{})
+	if err != nil {
+		t.Errorf("want nil, got: %v", err)
+		return
+	}
+	defer pr.Close()
+	want := []event{{1, "a"}, {2, "b"}}
+	if got := pr.Elements(nil); !reflect.DeepEqual(got, want) {
+		t.Errorf("want: %v, got: %v", want, got)
+	}
+}
+
+// headerFailingFile fails header writes when fail is true
+type headerFailingFile struct {
+	ringFile
+	fail bool
+}
+
+var errHeaderWrite = errors.New("header write fail")
+
+func (f *headerFailingFile) WriteAt(bs []byte, offset int64) (int, error) {
+	if f.fail && offset < ringFileDataOffset {
+		return 0, errHeaderWrite
+	}
+	return f.ringFile.WriteAt(bs, offset)
+}
+
+func TestPersistentRingHeaderWriteFail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ring")
+
+	pr, err := OpenPersistentRing[string](path, 3, 64, JSONCodec[string]{})
+	if err != nil {
+		t.Errorf("want nil, got: %v", err)
+		return
+	}
+	file := &headerFailingFile{ringFile: pr.file}
+	pr.file = file
+
+	pr.Push("a")
+	file.fail = true
+	if err := pr.Push("b"); !errors.Is(err, errHeaderWrite) {
+		t.Errorf("want: %v, got: %v", errHeaderWrite, err)
+	}
+	want := []string{"a", "b"}
+	if got := pr.Elements(nil); !reflect.DeepEqual(got, want) {
+		t.Errorf("want: %v, got: %v", want, got)
+	}
+	pr.Close()
+
+	// the record is recovered, memory and disk agree
+	pr, err = OpenPersistentRing[string](path, 3, 64, JSONCodec[string]{})
+	if err != nil {
+		t.Errorf("want nil, got: %v", err)
+		return
+	}
+	if got := pr.Elements(nil); !reflect.DeepEqual(got, want) {
+		t.Errorf("want: %v, got: %v", want, got)
+	}
+
+	file = &headerFailingFile{ringFile: pr.file, fail: true}
+	pr.file = file
+	pr.Push("c")
+	file.fail = false
+	pr.Push("d")
+	want = []string{"b", "c", "d"}
+	if got := pr.Elements(nil); !reflect.DeepEqual(got, want) {
+		t.Errorf("want: %v, got: %v", want, got)
+	}
+	pr.Close()
+
+	pr, err = OpenPersistentRing[string](path, 3, 64, JSONCodec[string]{})
+	if err != nil {
+		t.Errorf("want nil, got: %v", err)
+		return
+	}
+	if got := pr.Elements(nil); !reflect.DeepEqual(got, want) {
+		t.Errorf("want: %v, got: %v", want, got)
+	}
+	pr.Close()
+}
+
+// tearingFile writes only half of a record and fails when tear is true
+type tearingFile struct {
+	ringFile
+	tear bool
+}
+
+var errTornWrite = errors.New("torn write")
+
+func (f *tearingFile) WriteAt(bs []byte, offset int64) (int, error) {
+	if f.tear && offset >= ringFileDataOffset {
+		n, _ := f.ringFile.WriteAt(bs[:len(bs)/2], offset)
+		return n, errTornWrite
+	}
+	return f.ringFile.WriteAt(bs, offset)
+}
+
+func TestPersistentRingTornWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ring")
+
+	pr, err := OpenPersistentRing[string](path, 3, 64, JSONCodec[string]{})
+	if err != nil {
+		t.Errorf("want nil, got: %v", err)
+		return
+	}
+	file := &tearingFile{ringFile: pr.file}
+	pr.file = file
+
+	// not full, nothing is overwritten
+	file.tear = true
+	if err := pr.Push("aaaa"); !errors.Is(err, errTornWrite) {
+		t.Errorf("want: %v, got: %v", errTornWrite, err)
+	}
+	file.tear = false
+	for _, s := range []string{"aaaa", "bbbb", "cccc"} {
+		pr.Push(s)
+	}
+
+	// full, the oldest record is overwritten
+	file.tear = true
+	if err := pr.Push("dddd"); !errors.Is(err, errTornWrite) {
+		t.Errorf("want: %v, got: %v", errTornWrite, err)
+	}
+	want := []string{"bbbb", "cccc"}
+	if got := pr.Elements(nil); !reflect.DeepEqual(got, want) {
+		t.Errorf("want: %v, got: %v", want, got)
+	}
+	pr.Close()
+
+	pr, err = OpenPersistentRing[string](path, 3, 64, JSONCodec[string]{})
+	if err != nil {
+		t.Errorf("want nil, got: %v", err)
+		return
+	}
+	if got := pr.Elements(nil); !reflect.DeepEqual(got, want) {
+		t.Errorf("want: %v, got: %v", want, got)
+	}
+
+	pr.Push("eeee")
+	want = []string{"bbbb", "cccc", "eeee"}
+	if got := pr.Elements(nil); !reflect.DeepEqual(got, want) {
+		t.Errorf("want: %v, got: %v", want, got)
+	}
+	pr.Close()
+
+	pr, err = OpenPersistentRing[string](path, 3, 64, JSONCodec[string]{})
+	if err != nil {
+		t.Errorf("want nil, got: %v", err)
+		return
+	}
+	if got := pr.Elements(nil); !reflect.DeepEqual(got, want) {
+		t.Errorf("want: %v, got: %v", want, got)
+	}
+	pr.Close()
+}
+
+// writeRingFile write bs at offset of the ring file, to simulate a crash
+func writeRingFile(t *testing.T, path string, bs []byte, offset int64) {
+	f, err := os.OpenFile(path, os.O_RDWR, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteAt(bs, offset); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPersistentRingRecover(t *testing.T) {
+	newRingFile := func(values ...int) string {
+		path := filepath.Join(t.TempDir(), "ring")
+		pr, err := OpenPersistentRing[int](path, 4, 32, JSONCodec[int]{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, v := range values {
+			pr.Push(v)
+		}
+		pr.Close()
+		return path
+	}
+	slotOffset := func(seq int64) int64 { return ringFileDataOffset + (seq%4)*32 }
+
+	for _, cas := range []struct {
+		name   string
+		values []int
+		crash  func(path string)
+		want   []int
+	}{
+		{
+			name:   "torn latest header",
+			values: []int{1, 2, 3},
+			crash: func(path string) {
+				// generation 4 is in header A, previous header B has 2 records, record 3 is found by scan
+				writeRingFile(t, path, []byte("garbage"), 0)
+			},
+			want: []int{1, 2, 3},
+		},
+		{
+			name:   "record written but header not",
+			values: []int{1, 2, 3, 4, 5},
+			crash: func(path string) {
+				// write record 5(value 6) without header, it overwrites the oldest one(value 2)
+				record := []byte{1, 0, 0, 0, 0, 0, 0, 0, 5, 0, 0, 0, 0, 0, 0, 0, '6'}
+				binary.LittleEndian.PutUint32(record[4:], crc32.ChecksumIEEE(record[8:]))
+				writeRingFile(t, path, record, slotOffset(5))
+			},
+			want: []int{3, 4, 5, 6},
+		},
+		{
+			name:   "torn record over the oldest one",
+			values: []int{1, 2, 3, 4, 5},
+			crash: func(path string) {
+				writeRingFile(t, path, []byte{9, 9, 9, 9, 9, 9, 9, 9, 9}, slotOffset(5))
+			},
+			want: []int{3, 4, 5},
+		},
+		{
+			name:   "torn last record",
+			values: []int{1, 2, 3},
+			crash: func(path string) {
+				writeRingFile(t, path, []byte{9, 9, 9, 9, 9, 9, 9, 9, 9}, slotOffset(2))
+			},
+			want: []int{1, 2},
+		},
+	} {
+		path := newRingFile(cas.values...)
+		cas.crash(path)
+
+		pr, err := OpenPersistentRing[int](path, 4, 32, JSONCodec[int]{})
+		if err != nil {
+			t.Errorf("%s, want nil, got: %v", cas.name, err)
+			continue
+		}
+		if got := pr.Elements(nil); !reflect.DeepEqual(got, cas.want) {
+			t.Errorf("%s, want: %v, got: %v", cas.name, cas.want, got)
+		}
+
+		// keep working after recovery
+		pr.Push(100)
+		pr.Close()
+		pr, _ = OpenPersistentRing[int](path, 4, 32, JSONCodec[int]{})
+		want := append(cas.want, 100)
+		if len(want) > 4 {
+			want = want[len(want)-4:]
+		}
+		if got := pr.Elements(nil); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s, want: %v, got: %v", cas.name, want, got)
+		}
+		pr.Close()
+	}
+
+	// both headers are broken
+	path := newRingFile(1)
+	writeRingFile(t, path, make([]byte, ringFileDataOffset), 0)
+	if _, err := OpenPersistentRing[int](path, 4, 32, JSONCodec[int]{}); !errors.Is(err, ErrRingFileCorrupted) {
+		t.Errorf("want: %v, got: %v", ErrRingFileCorrupted, err)
+	}
+}
@@ -0,0 +1,152 @@
+package chper
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// RateCounter counts events in time buckets, e.g. count per second over the last 60 seconds
+// buckets are laid out circularly like Ring, the bucket of time t is at (t/width)%capacity,
+// stale buckets are reset lazily when time moves forward
+// Add is lock-free unless it resets a stale bucket, so it is safe and cheap under concurrent access
+type RateCounter struct {
+	buckets []rateBucket
+	width   time.Duration
+	now     func() time.Time
+}
+
+type rateBucket struct {
+	// epoch is the bucket's time index: UnixNano / width
+	epoch int64
+	count int64
+
+	// resetLock serializes resets of this bucket
+	resetLock sync.Mutex
+}
+
+type rateCounterOption struct {
+	now func() time.Time
+}
+
+type rateCounterOptionFunc func(*rateCounterOption)
+
+// RateCounterOptionClock specify the clock, default is time.Now
+func RateCounterOptionClock(now func() time.Time) rateCounterOptionFunc {
+	return func(ro *rateCounterOption) {
+		ro.now = now
+	}
+}
+
+// NewRateCounter create a RateCounter with capacity buckets, every bucket covers width
+func NewRateCounter(capacity int, width time.Duration, options ...rateCounterOptionFunc) *RateCounter {
+	if capacity < 1 {
+		panic("bad capacity")
+	}
+	if width <= 0 {
+		panic("bad width")
+	}
+
+	option := &rateCounterOption{now: time.Now}
+	for _, f := range options {
+		f(option)
+	}
+
+	rc := &RateCounter{
+		buckets: make([]rateBucket, capacity),
+		width:   width,
+		now:     option.now,
+	}
+	for i := range rc.buckets {
+		rc.buckets[i].epoch = -1
+	}
+
+	return rc
+}
+
+func (rc *RateCounter) epoch(t time.Time) int64 {
+	return t.UnixNano() / int64(rc.width)
+}
+
+func (rc *RateCounter) bucket(epoch int64) *rateBucket {
+	capacity := int64(len(rc.buckets))
+	return &rc.buckets[((epoch%capacity)+capacity)%capacity]
+}
+
+// Add count n events at now
+func (rc *RateCounter) Add(n int64) {
+	epoch := rc.epoch(rc.now())
+	b := rc.bucket(epoch)
+
+	for {
+		current := atomic.LoadInt64(&b.epoch)
+		if current == epoch {
+			atomic.AddInt64(&b.count, n)
+			return
+		}
+		if current > epoch { // too late, the bucket is reused
+			return
+		}
+
+		b.resetLock.Lock()
+		if atomic.LoadInt64(&b.epoch) < epoch {
+			// count must be reset before epoch is published
+			atomic.StoreInt64(&b.count, 0)
+			atomic.StoreInt64(&b.epoch, epoch)
+		}
+		b.resetLock.Unlock()
+	}
+}
+
+// Sum return events count in the last window, window is rounded up to bucket width
+// the current bucket is included even if it is not finished
+func (rc *RateCounter) Sum(window time.Duration) int64 {
+	n := int64((window + rc.width - 1) / rc.width)
+	if n > int64(len(rc.buckets)) {
+		n = int64(len(rc.buckets))
+	}
+
+	current := rc.epoch(rc.now())
+	sum := int64(0)
+	for i := range rc.buckets {
+		b := &rc.buckets[i]
+		epoch := atomic.LoadInt64(&b.epoch)
+		if epoch > current-n && epoch <= current {
+			sum += atomic.LoadInt64(&b.count)
+		}
+	}
+
+	return sum
+}
+
+// Rate return events count per second over all buckets
+func (rc *RateCounter) Rate() float64 {
+	window := rc.width * time.Duration(len(rc.buckets))
+	return float64(rc.Sum(window)) / window.Seconds()
+}
+
+// RateBucket is a snapshot of one bucket
+type RateBucket struct {
+	Start time.Time
+	Count int64
+}
+
+// Snapshot return all buckets, sorted by time, the last one is the current bucket
+// buckets without events have zero Count
+func (rc *RateCounter) Snapshot() []RateBucket {
+	current := rc.epoch(rc.now())
+	capacity := int64(len(rc.buckets))
+
+	snapshot := make([]RateBucket, capacity)
+	for i := int64(0); i < capacity; i++ {
+		epoch := current - capacity + 1 + i
+		snapshot[i].Start = time.Unix(0, epoch*int64(rc.width))
+
+		b := rc.bucket(epoch)
+		if atomic.LoadInt64(&b.epoch) == epoch {
+			snapshot[i].Count = atomic.LoadInt64(&b.count)
+		}
+	}
+
+	return snapshot
+}
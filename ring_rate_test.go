@@ -0,0 +1,110 @@
+package chper
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRateCounter(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	rc := NewRateCounter(5, time.Second, RateCounterOptionClock(clock.Now))
+
+	if got := rc.Rate(); got != 0 {
+		t.Errorf("want: 0, got: %v", got)
+	}
+
+	// second 1000..1003: 1 2 3 4
+	for i := int64(1); i <= 4; i++ {
+		rc.Add(i)
+		clock.Add(time.Second)
+	}
+	rc.Add(5) // second 1004
+
+	for _, cas := range []struct {
+		window time.Duration
+		want   int64
+	}{
+		{window: 0, want: 0},
+		{window: time.Second, want: 5},
+		{window: 1500 * time.Millisecond, want: 9},
+		{window: 3 * time.Second, want: 12},
+		{window: time.Hour, want: 15},
+	} {
+		if got := rc.Sum(cas.window); got != cas.want {
+			t.Errorf("window: %v, want: %v, got: %v", cas.window, cas.want, got)
+		}
+	}
+	if got := rc.Rate(); got != 3 {
+		t.Errorf("want: 3, got: %v", got)
+	}
+
+	// second 1006, bucket of 1001 and 1002 is reused or stale
+	clock.Add(2 * time.Second)
+	rc.Add(10)
+	want := []RateBucket{
+		{Start: time.Unix(1002, 0), Count: 3},
+		{Start: time.Unix(1003, 0), Count: 4},
+		{Start: time.Unix(1004, 0), Count: 5},
+		{Start: time.Unix(1005, 0), Count: 0},
+		{Start: time.Unix(1006, 0), Count: 10},
+	}
+	if got := rc.Snapshot(); !reflect.DeepEqual(got, want) {
+		t.Errorf("want: %v, got: %v", want, got)
+	}
+	if got := rc.Sum(time.Hour); got != 22 {
+		t.Errorf("want: 22, got: %v", got)
+	}
+
+	clock.Add(time.Hour)
+	if got := rc.Sum(time.Hour); got != 0 {
+		t.Errorf("want: 0, got: %v", got)
+	}
+}
+
+func TestRateCounterConcurrent(t *testing.T) {
+	var lock sync.Mutex
+	now := time.Unix(1000, 0)
+	clock := func() time.Time {
+		lock.Lock()
+		defer lock.Unlock()
+		return now
+	}
+	rc := NewRateCounter(60, time.Second, RateCounterOptionClock(clock))
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				rc.Add(1)
+				if i%100 == 0 {
+					rc.Sum(time.Minute)
+				}
+			}
+		}()
+	}
+	go func() {
+		for i := 0; i < 10; i++ {
+			lock.Lock()
+			now = now.Add(time.Second)
+			lock.Unlock()
+		}
+	}()
+	wg.Wait()
+
+	if got := rc.Sum(time.Minute); got != 8000 {
+		t.Errorf("want: 8000, got: %v", got)
+	}
+}
+
+func BenchmarkRateCounter(b *testing.B) {
+	rc := NewRateCounter(60, time.Second)
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			rc.Add(1)
+		}
+	})
+}
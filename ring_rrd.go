@@ -0,0 +1,187 @@
+package chper
+
+import (
+	"sync"
+	"time"
+)
+
+// Consolidation combine samples into one value
+type Consolidation int
+
+const (
+	ConsolidateAvg Consolidation = iota
+	ConsolidateMin
+	ConsolidateMax
+	ConsolidateSum
+	ConsolidateLast
+)
+
+func (c Consolidation) consolidate(samples []Sample) float64 {
+	value := samples[0].Value
+	for _, s := range samples[1:] {
+		switch c {
+		case ConsolidateMin:
+			if s.Value < value {
+				value = s.Value
+			}
+		case ConsolidateMax:
+			if s.Value > value {
+				value = s.Value
+			}
+		case ConsolidateAvg, ConsolidateSum:
+			value += s.Value
+		case ConsolidateLast:
+			value = s.Value
+		}
+	}
+
+	if c == ConsolidateAvg {
+		value /= float64(len(samples))
+	}
+
+	return value
+}
+
+// Sample is a time-series point
+type Sample struct {
+	At    time.Time
+	Value float64
+}
+
+// RRDLevel is the config of one resolution
+type RRDLevel struct {
+	// Step is the sample interval, a coarser level's Step must be a multiple of the finer one's
+	Step time.Duration
+	// Capacity is the samples count kept in the level
+	Capacity int
+}
+
+// RRD is a round-robin database built from several Rings, from the finest level to the coarsest level
+// e.g. 1-second samples for an hour, 1-minute samples for a day and 1-hour samples for a month
+// when a level overflows, its evicted samples of the same coarser step are consolidated
+// into one sample of the next level
+type RRD struct {
+	levels        []*rrdLevel
+	consolidation Consolidation
+
+	lock sync.Mutex
+}
+
+type rrdLevel struct {
+	step time.Duration
+	ring *Ring[Sample]
+
+	// pending is the evicted samples of the finer level, which belong to the same step of this level
+	pending []Sample
+}
+
+// NewRRD create a RRD, levels must be sorted from the finest to the coarsest
+func NewRRD(consolidation Consolidation, levels ...RRDLevel) *RRD {
+	if len(levels) == 0 {
+		panic("want at least one level")
+	}
+
+	rrd := &RRD{consolidation: consolidation}
+	for i, l := range levels {
+		if l.Step <= 0 {
+			panic("bad step")
+		}
+		if i > 0 && l.Step%levels[i-1].Step != 0 {
+			panic("step must be a multiple of the finer level's step")
+		}
+
+		i := i
+		rrd.levels = append(rrd.levels, &rrdLevel{
+			step: l.Step,
+			ring: NewRing[Sample](l.Capacity, RingOptionOnEvict(func(s Sample) {
+				rrd.cascade(i+1, s)
+			})),
+		})
+	}
+
+	return rrd
+}
+
+// cascade move sample evicted from level i-1 into level i
+// it is called by Ring's onEvict in Add, so RRD is locked
+func (rrd *RRD) cascade(i int, s Sample) {
+	if i >= len(rrd.levels) {
+		return
+	}
+
+	level := rrd.levels[i]
+	if len(level.pending) > 0 && !level.pending[0].At.Truncate(level.step).Equal(s.At.Truncate(level.step)) {
+		level.flush(rrd.consolidation)
+	}
+	level.pending = append(level.pending, s)
+}
+
+func (l *rrdLevel) flush(consolidation Consolidation) {
+	l.ring.Push(Sample{
+		At:    l.pending[0].At.Truncate(l.step),
+		Value: consolidation.consolidate(l.pending),
+	})
+	l.pending = l.pending[:0]
+}
+
+// Add append one sample to the finest level, samples must be added in time order
+func (rrd *RRD) Add(at time.Time, value float64) {
+	rrd.lock.Lock()
+	defer rrd.lock.Unlock()
+
+	rrd.levels[0].ring.Push(Sample{At: at, Value: value})
+}
+
+// Level return samples of level i, sorted by time
+func (rrd *RRD) Level(i int) []Sample {
+	rrd.lock.Lock()
+	defer rrd.lock.Unlock()
+
+	return rrd.levels[i].ring.Elements(nil)
+}
+
+// Query return samples in [from, to], sorted by time
+// levels are combined: the newest part comes from the finest level, older parts from coarser levels,
+// including samples waiting to be consolidated, so the resolution decreases with the age
+func (rrd *RRD) Query(from, to time.Time) (samples []Sample) {
+	rrd.lock.Lock()
+	defer rrd.lock.Unlock()
+
+	// parts are from the newest to the oldest, covered is the oldest time of the newer parts
+	var parts [][]Sample
+	var covered time.Time
+	take := func(candidates []Sample) {
+		if len(candidates) == 0 {
+			return
+		}
+
+		var part []Sample
+		for _, s := range candidates {
+			if !covered.IsZero() && !s.At.Before(covered) {
+				break
+			}
+			if !s.At.Before(from) && !s.At.After(to) {
+				part = append(part, s)
+			}
+		}
+		parts = append(parts, part)
+
+		if covered.IsZero() || candidates[0].At.Before(covered) {
+			covered = candidates[0].At
+		}
+	}
+
+	for i, l := range rrd.levels {
+		// pending samples are evicted from level i-1, they are newer than level i's samples
+		if i > 0 {
+			take(l.pending)
+		}
+		take(l.ring.Elements(nil))
+	}
+
+	for i := len(parts) - 1; i >= 0; i-- {
+		samples = append(samples, parts[i]...)
+	}
+
+	return
+}
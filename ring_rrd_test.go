@@ -0,0 +1,140 @@
+package chper
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRRD(t *testing.T) {
+	begin := time.Unix(3600, 0)
+	at := func(seconds int) time.Time { return begin.Add(time.Duration(seconds) * time.Second) }
+
+	rrd := NewRRD(ConsolidateAvg,
+		RRDLevel{Step: time.Second, Capacity: 4},
+		RRDLevel{Step: 2 * time.Second, Capacity: 2},
+		RRDLevel{Step: 4 * time.Second, Capacity: 10},
+	)
+
+	if got := rrd.Query(begin, at(100)); got != nil {
+		t.Errorf("want nil, got: %v", got)
+	}
+
+	for i := 0; i < 18; i++ {
+		rrd.Add(at(i), float64(i))
+	}
+
+	// level 0 keeps 14..17, 0..13 are evicted
+	// level 1 gets avg(0,1) avg(2,3) ... avg(10,11), 12 13 are pending, keeps avg(8,9) avg(10,11)
+	// level 2 gets avg(avg(0,1), avg(2,3)), avg(4,5) avg(6,7) are pending
+	want := []Sample{{at(14), 14}, {at(15), 15}, {at(16), 16}, {at(17), 17}}
+	if got := rrd.Level(0); !reflect.DeepEqual(got, want) {
+		t.Errorf("want: %v, got: %v", want, got)
+	}
+	want = []Sample{{at(8), 8.5}, {at(10), 10.5}}
+	if got := rrd.Level(1); !reflect.DeepEqual(got, want) {
+		t.Errorf("want: %v, got: %v", want, got)
+	}
+	want = []Sample{{at(0), 1.5}}
+	if got := rrd.Level(2); !reflect.DeepEqual(got, want) {
+		t.Errorf("want: %v, got: %v", want, got)
+	}
+
+	all := []Sample{
+		{at(0), 1.5},               // level 2
+		{at(4), 4.5}, {at(6), 6.5}, // pending of level 2
+		{at(8), 8.5}, {at(10), 10.5}, // level 1
+		{at(12), 12}, {at(13), 13}, // pending of level 1
+		{at(14), 14}, {at(15), 15}, {at(16), 16}, {at(17), 17}, // level 0
+	}
+	for _, cas := range []struct {
+		name     string
+		from, to time.Time
+		want     []Sample
+	}{
+		{name: "recent", from: at(15), to: at(16), want: []Sample{{at(15), 15}, {at(16), 16}}},
+		{name: "middle", from: at(9), to: at(100), want: all[4:]},
+		{name: "old", from: at(0), to: at(100), want: all},
+		{name: "old part", from: at(3), to: at(12), want: all[1:6]},
+		{name: "before all", from: at(-100), to: at(1), want: all[:1]},
+		{name: "after all", from: at(18), to: at(100), want: nil},
+	} {
+		if got := rrd.Query(cas.from, cas.to); !reflect.DeepEqual(got, cas.want) {
+			t.Errorf("%s, want: %v, got: %v", cas.name, cas.want, got)
+		}
+	}
+}
+
+func TestRRDQueryRecent(t *testing.T) {
+	begin := time.Unix(0, 0)
+	rrd := NewRRD(ConsolidateAvg,
+		RRDLevel{Step: time.Second, Capacity: 3600},
+		RRDLevel{Step: time.Minute, Capacity: 1440},
+	)
+	for i := 0; i < 7200; i++ {
+		rrd.Add(begin.Add(time.Duration(i)*time.Second), float64(i))
+	}
+
+	// minutes 0..58 are consolidated, minute 59 is pending, the last hour is in level 0
+	got := rrd.Query(begin, begin.Add(2*time.Hour))
+	if len(got) != 59+60+3600 {
+		t.Errorf("want: %d, got: %d", 59+60+3600, len(got))
+	}
+	for i := 1; i < len(got); i++ {
+		if !got[i-1].At.Before(got[i].At) {
+			t.Errorf("want sorted by time, got: %v %v", got[i-1], got[i])
+		}
+	}
+	if last := got[len(got)-1]; last.Value != 7199 {
+		t.Errorf("want: 7199, got: %v", last)
+	}
+	if first := got[0]; first.Value != 29.5 {
+		t.Errorf("want: 29.5, got: %v", first)
+	}
+}
+
+func TestConsolidation(t *testing.T) {
+	samples := []Sample{{Value: 3}, {Value: 1}, {Value: 5}, {Value: 2}}
+	for _, cas := range []struct {
+		consolidation Consolidation
+		want          float64
+	}{
+		{consolidation: ConsolidateAvg, want: 2.75},
+		{consolidation: ConsolidateMin, want: 1},
+		{consolidation: ConsolidateMax, want: 5},
+		{consolidation: ConsolidateSum, want: 11},
+		{consolidation: ConsolidateLast, want: 2},
+	} {
+		if got := cas.consolidation.consolidate(samples); got != cas.want {
+			t.Errorf("consolidation: %v, want: %v, got: %v", cas.consolidation, cas.want, got)
+		}
+	}
+}
+
+func TestRRDMax(t *testing.T) {
+	begin := time.Unix(0, 0)
+	rrd := NewRRD(ConsolidateMax,
+		RRDLevel{Step: time.Second, Capacity: 60},
+		RRDLevel{Step: time.Minute, Capacity: 60},
+	)
+	for i := 0; i < 600; i++ {
+		rrd.Add(begin.Add(time.Duration(i)*time.Second), float64(i%60))
+	}
+
+	got := rrd.Level(1)
+	if len(got) != 8 {
+		t.Errorf("want: 8, got: %v", len(got))
+	}
+	for i, s := range got {
+		if s.Value != 59 || !s.At.Equal(begin.Add(time.Duration(i)*time.Minute)) {
+			t.Errorf("want: %v 59, got: %v", begin.Add(time.Duration(i)*time.Minute), s)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("want panic")
+		}
+	}()
+	NewRRD(ConsolidateAvg, RRDLevel{Step: 2 * time.Second, Capacity: 1}, RRDLevel{Step: 3 * time.Second, Capacity: 1})
+}
@@ -0,0 +1,134 @@
+package chper
+
+import (
+	"container/list"
+	"sync"
+)
+
+// SetRing is a Ring without duplicate elements, e.g. "recently viewed items"
+// all element sorted by Push index, Push an existing element moves it to the newest position
+// early elements will be removed if SetRing is filled
+type SetRing[V comparable] struct {
+	capacity int
+
+	// elements is sorted from the oldest to the newest, index maps element to its list node
+	elements *list.List
+	index    map[V]*list.Element
+
+	lock sync.Mutex
+}
+
+func NewSetRing[V comparable](capacity int) *SetRing[V] {
+	if capacity < 1 {
+		panic("bad capacity")
+	}
+
+	return &SetRing[V]{
+		capacity: capacity,
+		elements: list.New(),
+		index:    make(map[V]*list.Element, capacity),
+	}
+}
+
+// Push append one element, or move it to the newest position if it exists
+// return the evicted element and whether there is one
+func (sr *SetRing[V]) Push(data V) (evicted V, ok bool) {
+	sr.lock.Lock()
+	defer sr.lock.Unlock()
+
+	if e, exist := sr.index[data]; exist {
+		sr.elements.MoveToBack(e)
+		return
+	}
+
+	if sr.elements.Len() == sr.capacity {
+		evicted, ok = sr.elements.Remove(sr.elements.Front()).(V), true
+		delete(sr.index, evicted)
+	}
+	sr.index[data] = sr.elements.PushBack(data)
+
+	return
+}
+
+// Contains return whether data is in SetRing
+func (sr *SetRing[V]) Contains(data V) bool {
+	sr.lock.Lock()
+	defer sr.lock.Unlock()
+
+	_, exist := sr.index[data]
+	return exist
+}
+
+// Remove remove data and return whether it exists
+func (sr *SetRing[V]) Remove(data V) bool {
+	sr.lock.Lock()
+	defer sr.lock.Unlock()
+
+	e, exist := sr.index[data]
+	if !exist {
+		return false
+	}
+
+	sr.elements.Remove(e)
+	delete(sr.index, data)
+	return true
+}
+
+// Elements return matched elements, sorted by push index
+func (sr *SetRing[V]) Elements(filter func(V) bool) (elements []V) {
+	sr.lock.Lock()
+	defer sr.lock.Unlock()
+
+	for e := sr.elements.Front(); e != nil; e = e.Next() {
+		ele := e.Value.(V)
+		if filter == nil || filter(ele) {
+			elements = append(elements, ele)
+		}
+	}
+
+	return
+}
+
+// First return first(oldest) element and exist
+func (sr *SetRing[V]) First() (v V, ok bool) {
+	sr.lock.Lock()
+	defer sr.lock.Unlock()
+
+	if e := sr.elements.Front(); e != nil {
+		return e.Value.(V), true
+	}
+	return
+}
+
+// Last return last(newest) element and exist
+func (sr *SetRing[V]) Last() (v V, ok bool) {
+	sr.lock.Lock()
+	defer sr.lock.Unlock()
+
+	if e := sr.elements.Back(); e != nil {
+		return e.Value.(V), true
+	}
+	return
+}
+
+// Size return elements count
+func (sr *SetRing[V]) Size() int {
+	sr.lock.Lock()
+	defer sr.lock.Unlock()
+
+	return sr.elements.Len()
+}
+
+// Capacity return SetRing's capacity
+func (sr *SetRing[V]) Capacity() int {
+	return sr.capacity
+}
+
+// Clear remove all elements
+func (sr *SetRing[V]) Clear() {
+	sr.lock.Lock()
+	defer sr.lock.Unlock()
+
+	sr.elements.Init()
+	clear(sr.index)
+}
@@ -0,0 +1,73 @@
+package chper
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSetRing(t *testing.T) {
+	sr := NewSetRing[string](3)
+
+	if _, ok := sr.First(); ok {
+		t.Errorf("want not exist, got exist")
+	}
+
+	for _, cas := range []struct {
+		push        string
+		want        []string
+		wantEvicted string
+	}{
+		{push: "a", want: []string{"a"}},
+		{push: "b", want: []string{"a", "b"}},
+		{push: "a", want: []string{"b", "a"}},
+		{push: "c", want: []string{"b", "a", "c"}},
+		{push: "c", want: []string{"b", "a", "c"}},
+		{push: "d", want: []string{"a", "c", "d"}, wantEvicted: "b"},
+		{push: "a", want: []string{"c", "d", "a"}},
+		{push: "e", want: []string{"d", "a", "e"}, wantEvicted: "c"},
+	} {
+		evicted, ok := sr.Push(cas.push)
+		if ok != (cas.wantEvicted != "") || evicted != cas.wantEvicted {
+			t.Errorf("push: %s, want evicted: %q, got: %q %v", cas.push, cas.wantEvicted, evicted, ok)
+		}
+		if got := sr.Elements(nil); !reflect.DeepEqual(got, cas.want) {
+			t.Errorf("push: %s, want: %v, got: %v", cas.push, cas.want, got)
+		}
+	}
+
+	if !sr.Contains("a") || sr.Contains("b") {
+		t.Errorf("want a in and b not in, got: %v", sr.Elements(nil))
+	}
+	if first, _ := sr.First(); first != "d" {
+		t.Errorf("want: d, got: %v", first)
+	}
+	if last, _ := sr.Last(); last != "e" {
+		t.Errorf("want: e, got: %v", last)
+	}
+	if got := sr.Elements(func(s string) bool { return s != "a" }); !reflect.DeepEqual(got, []string{"d", "e"}) {
+		t.Errorf("want: [d e], got: %v", got)
+	}
+
+	if !sr.Remove("a") || sr.Remove("a") {
+		t.Errorf("want remove a once")
+	}
+	sr.Push("f")
+	if want, got := []string{"d", "e", "f"}, sr.Elements(nil); !reflect.DeepEqual(want, got) {
+		t.Errorf("want: %v, got: %v", want, got)
+	}
+	if sr.Size() != 3 || sr.Capacity() != 3 {
+		t.Errorf("want: 3 3, got: %d %d", sr.Size(), sr.Capacity())
+	}
+
+	sr.Clear()
+	if sr.Size() != 0 || sr.Contains("d") {
+		t.Errorf("want empty, got: %v", sr.Elements(nil))
+	}
+}
+
+func BenchmarkSetRingPush(b *testing.B) {
+	sr := NewSetRing[int](1024)
+	for i := 0; i < b.N; i++ {
+		sr.Push(i % 2048)
+	}
+}
@@ -0,0 +1,125 @@
+package chper
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrElementTooLarge = errors.New("element too large")
+	ErrBadElementSize  = errors.New("element size must be greater than zero")
+)
+
+// SizedRing is a Ring bounded by the total bytes of elements instead of the count
+// Push evicts the oldest elements until the new one fits the budget
+type SizedRing[V any] struct {
+	ring   *Ring[sizedElement[V]]
+	sizeOf func(V) int
+
+	budget int
+	bytes  int
+}
+
+// sizedElement keeps the size computed by Push, so mutating an element later does not change the accounting
+type sizedElement[V any] struct {
+	size int
+	data V
+}
+
+// sizedRingMinCapacity is the initial and the min capacity of the underlying Ring
+const sizedRingMinCapacity = 16
+
+// NewSizedRing create a SizedRing, sizeOf return the bytes of one element, budget is the max total bytes
+func NewSizedRing[V any](budget int, sizeOf func(V) int) *SizedRing[V] {
+	if budget < 1 {
+		panic("bad budget")
+	}
+
+	return &SizedRing[V]{
+		ring:   NewRing[sizedElement[V]](sizedRingMinCapacity),
+		sizeOf: sizeOf,
+		budget: budget,
+	}
+}
+
+// Push append one element, the oldest elements are evicted until it fits
+// an element larger than the budget is rejected with ErrElementTooLarge,
+// an element whose size is less than 1 is rejected with ErrBadElementSize
+func (sr *SizedRing[V]) Push(data V) error {
+	size := sr.sizeOf(data)
+	if size < 1 {
+		return fmt.Errorf("%w, size: %d", ErrBadElementSize, size)
+	}
+	if size > sr.budget {
+		return fmt.Errorf("%w, size: %d, budget: %d", ErrElementTooLarge, size, sr.budget)
+	}
+
+	sr.ring.lock.Lock()
+	defer sr.ring.lock.Unlock()
+
+	evicted := false
+	for sr.bytes+size > sr.budget && sr.ring.size > 0 {
+		e, _ := sr.ring.popFront()
+		sr.bytes -= e.size
+		sr.ring.evicted++
+		evicted = true
+	}
+
+	// the count is bounded by the budget, grow instead of overwriting, shrink if mostly empty
+	if sr.ring.size == sr.ring.capacity {
+		sr.ring.resize(2 * sr.ring.capacity)
+	} else if evicted && sr.ring.capacity > sizedRingMinCapacity && sr.ring.size < sr.ring.capacity/4 {
+		sr.ring.resize(sr.ring.capacity / 2)
+	}
+	sr.ring.push(sizedElement[V]{size: size, data: data})
+	sr.bytes += size
+
+	return nil
+}
+
+// Elements return matched elements, sorted by push index
+func (sr *SizedRing[V]) Elements(filter func(V) bool) (elements []V) {
+	sr.ring.Range(func(_ int, e sizedElement[V]) bool {
+		if filter == nil || filter(e.data) {
+			elements = append(elements, e.data)
+		}
+		return true
+	})
+
+	return
+}
+
+// First return first element and exist
+func (sr *SizedRing[V]) First() (V, bool) {
+	e, ok := sr.ring.First()
+	return e.data, ok
+}
+
+// Last return last element and exist
+func (sr *SizedRing[V]) Last() (V, bool) {
+	e, ok := sr.ring.Last()
+	return e.data, ok
+}
+
+// Size return ring's elements count
+func (sr *SizedRing[V]) Size() int {
+	return sr.ring.Size()
+}
+
+// Bytes return the total bytes of elements
+func (sr *SizedRing[V]) Bytes() int {
+	sr.ring.lock.Lock()
+	defer sr.ring.lock.Unlock()
+
+	return sr.bytes
+}
+
+// Budget return the max total bytes
+func (sr *SizedRing[V]) Budget() int {
+	return sr.budget
+}
+
+// Stats return ring's counters, Evicted counts elements evicted by the budget
+func (sr *SizedRing[V]) Stats() RingStats {
+	return sr.ring.Stats()
+}
@@ -0,0 +1,132 @@
+package chper
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSizedRing(t *testing.T) {
+	sr := NewSizedRing(10, func(s string) int { return len(s) })
+
+	if _, ok := sr.First(); ok {
+		t.Errorf("want not exist, got exist")
+	}
+
+	for _, cas := range []struct {
+		push      string
+		want      []string
+		wantBytes int
+	}{
+		{push: "aaa", want: []string{"aaa"}, wantBytes: 3},
+		{push: "bbb", want: []string{"aaa", "bbb"}, wantBytes: 6},
+		{push: "cccc", want: []string{"aaa", "bbb", "cccc"}, wantBytes: 10},
+		{push: "d", want: []string{"bbb", "cccc", "d"}, wantBytes: 8},
+		{push: "eeeeeeee", want: []string{"d", "eeeeeeee"}, wantBytes: 9},
+		{push: "ffffffffff", want: []string{"ffffffffff"}, wantBytes: 10},
+	} {
+		if err := sr.Push(cas.push); err != nil {
+			t.Errorf("push: %s, want nil, got: %v", cas.push, err)
+		}
+		if got := sr.Elements(nil); !reflect.DeepEqual(got, cas.want) {
+			t.Errorf("push: %s, want: %v, got: %v", cas.push, cas.want, got)
+		}
+		if got := sr.Bytes(); got != cas.wantBytes {
+			t.Errorf("push: %s, want: %d, got: %d", cas.push, cas.wantBytes, got)
+		}
+	}
+
+	if err := sr.Push("ggggggggggg"); !errors.Is(err, ErrElementTooLarge) {
+		t.Errorf("want ErrElementTooLarge, got: %v", err)
+	}
+	if want, got := []string{"ffffffffff"}, sr.Elements(nil); !reflect.DeepEqual(want, got) {
+		t.Errorf("want: %v, got: %v", want, got)
+	}
+	if got := sr.Stats().Evicted; got != 5 {
+		t.Errorf("want: 5, got: %v", got)
+	}
+}
+
+func TestSizedRingGrow(t *testing.T) {
+	sr := NewSizedRing(1000, func(s string) int { return len(s) })
+
+	var want []string
+	for i := 0; i < 100; i++ {
+		s := strings.Repeat("x", i%10+1)
+		sr.Push(s)
+		want = append(want, s)
+	}
+	for len(strings.Join(want, "")) > 1000 {
+		want = want[1:]
+	}
+
+	if got := sr.Elements(nil); !reflect.DeepEqual(got, want) {
+		t.Errorf("want: %v, got: %v", want, got)
+	}
+	if first, _ := sr.First(); first != want[0] {
+		t.Errorf("want: %v, got: %v", want[0], first)
+	}
+	if last, _ := sr.Last(); last != want[len(want)-1] {
+		t.Errorf("want: %v, got: %v", want[len(want)-1], last)
+	}
+	if sr.Size() != len(want) || sr.Bytes() != len(strings.Join(want, "")) || sr.Budget() != 1000 {
+		t.Errorf("want: %d %d, got: %d %d", len(want), len(strings.Join(want, "")), sr.Size(), sr.Bytes())
+	}
+}
+
+func TestSizedRingMutatedElement(t *testing.T) {
+	type box struct {
+		n int
+	}
+	sr := NewSizedRing(10, func(b *box) int { return b.n })
+
+	b := &box{n: 8}
+	sr.Push(b)
+	b.n = 1 // the recorded size is still 8
+
+	if err := sr.Push(&box{n: 5}); err != nil {
+		t.Errorf("want nil, got: %v", err)
+	}
+	if got := sr.Bytes(); got != 5 {
+		t.Errorf("want: 5, got: %v", got)
+	}
+	if got := sr.Size(); got != 1 {
+		t.Errorf("want: 1, got: %v", got)
+	}
+}
+
+func TestSizedRingBadSize(t *testing.T) {
+	sr := NewSizedRing(10, func(s string) int { return len(s) })
+
+	for i := 0; i < 1000; i++ {
+		if err := sr.Push(""); !errors.Is(err, ErrBadElementSize) {
+			t.Errorf("want: %v, got: %v", ErrBadElementSize, err)
+			return
+		}
+	}
+	if got := sr.Size(); got != 0 {
+		t.Errorf("want: 0, got: %v", got)
+	}
+}
+
+func TestSizedRingShrink(t *testing.T) {
+	sr := NewSizedRing(1000, func(s string) int { return len(s) })
+
+	for i := 0; i < 1000; i++ {
+		sr.Push("x")
+	}
+	if got := sr.ring.Capacity(); got != 1024 {
+		t.Errorf("want: 1024, got: %v", got)
+	}
+
+	for i := 0; i < 10; i++ {
+		sr.Push(strings.Repeat("y", 500))
+	}
+	if got := sr.Size(); got != 2 {
+		t.Errorf("want: 2, got: %v", got)
+	}
+	if got := sr.ring.Capacity(); got > 64 {
+		t.Errorf("want at most 64, got: %v", got)
+	}
+}
@@ -0,0 +1,144 @@
+package chper
+
+import "context"
+
+// SlowSubscriberPolicy specify what Push does when a subscriber's channel is full
+type SlowSubscriberPolicy int
+
+const (
+	// SlowSubscriberDrop drop the element for the subscriber
+	SlowSubscriberDrop SlowSubscriberPolicy = iota
+	// SlowSubscriberDisconnect close the subscriber's channel
+	SlowSubscriberDisconnect
+)
+
+type subscribeOption struct {
+	buffer int
+	policy SlowSubscriberPolicy
+}
+
+type subscribeOptionFunc func(*subscribeOption)
+
+// SubscribeOptionBuffer specify the channel buffer for new elements, default is 64
+// the replayed elements do not use the buffer
+func SubscribeOptionBuffer(buffer int) subscribeOptionFunc {
+	return func(so *subscribeOption) {
+		so.buffer = buffer
+	}
+}
+
+// SubscribeOptionPolicy specify the policy of slow subscriber, default is SlowSubscriberDrop
+func SubscribeOptionPolicy(policy SlowSubscriberPolicy) subscribeOptionFunc {
+	return func(so *subscribeOption) {
+		so.policy = policy
+	}
+}
+
+type ringSubscriber[V any] struct {
+	ch     chan V
+	policy SlowSubscriberPolicy
+	// done is closed when the subscriber is released, to stop the ctx watcher
+	done chan struct{}
+}
+
+// Subscribe return a channel which replays the current elements first, then receives every new element
+// Push never blocks on subscribers, a slow subscriber behaves as SlowSubscriberPolicy
+// the channel is closed when ctx is done, Unsubscribe is called or the subscriber is disconnected
+// a ctx which is never done (e.g. context.Background()) starts no goroutine,
+// the subscription lives until Unsubscribe or disconnection
+func (r *Ring[V]) Subscribe(ctx context.Context, options ...subscribeOptionFunc) <-chan V {
+	option := &subscribeOption{buffer: 64}
+	for _, f := range options {
+		f(option)
+	}
+	if option.buffer < 0 {
+		panic("bad buffer")
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	sub := &ringSubscriber[V]{
+		ch:     make(chan V, r.size+option.buffer),
+		policy: option.policy,
+		done:   make(chan struct{}),
+	}
+	for i := 0; i < r.size; i++ {
+		sub.ch <- r.at(i)
+	}
+
+	if ctx.Err() != nil {
+		close(sub.ch)
+		return sub.ch
+	}
+
+	if r.subscribers == nil {
+		r.subscribers = map[*ringSubscriber[V]]struct{}{}
+	}
+	r.subscribers[sub] = struct{}{}
+
+	if ctx.Done() == nil {
+		return sub.ch
+	}
+
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-sub.done:
+			return
+		}
+
+		r.lock.Lock()
+		if _, ok := r.subscribers[sub]; ok {
+			r.unsubscribe(sub)
+		}
+		r.lock.Unlock()
+	}()
+
+	return sub.ch
+}
+
+// Unsubscribe close the channel returned by Subscribe and release it, return false if it is already released
+func (r *Ring[V]) Unsubscribe(ch <-chan V) bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	for sub := range r.subscribers {
+		if sub.ch == ch {
+			r.unsubscribe(sub)
+			return true
+		}
+	}
+
+	return false
+}
+
+// unsubscribe must be called with lock held
+func (r *Ring[V]) unsubscribe(sub *ringSubscriber[V]) {
+	delete(r.subscribers, sub)
+	close(sub.ch)
+	close(sub.done)
+}
+
+// Subscribers return the count of subscribers
+func (r *Ring[V]) Subscribers() int {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return len(r.subscribers)
+}
+
+// publish send data to all subscribers without blocking, it must be called with lock held
+func (r *Ring[V]) publish(data V) {
+	for sub := range r.subscribers {
+		select {
+		case sub.ch <- data:
+			continue
+		default:
+		}
+
+		if sub.policy == SlowSubscriberDisconnect {
+			r.unsubscribe(sub)
+		}
+	}
+}
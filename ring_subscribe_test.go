@@ -0,0 +1,198 @@
+package chper
+
+import (
+	"context"
+	"reflect"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+// receive read n elements from ch, or fail after one second
+func receive[V any](t *testing.T, ch <-chan V, n int) (got []V) {
+	t.Helper()
+
+	for i := 0; i < n; i++ {
+		select {
+		case v, ok := <-ch:
+			if !ok {
+				t.Fatalf("channel closed, got: %v", got)
+			}
+			got = append(got, v)
+		case <-time.After(time.Second):
+			t.Fatalf("timeout, got: %v", got)
+		}
+	}
+
+	return
+}
+
+func waitClosed[V any](t *testing.T, ch <-chan V) {
+	t.Helper()
+
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case _, ok := <-ch:
+			if !ok {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("want channel closed")
+		}
+	}
+}
+
+func TestRingSubscribe(t *testing.T) {
+	r := NewRing[int](3)
+	for i := 1; i <= 4; i++ {
+		r.Push(i)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := r.Subscribe(ctx)
+
+	if want, got := []int{2, 3, 4}, receive(t, ch, 3); !reflect.DeepEqual(want, got) {
+		t.Errorf("replay, want: %v, got: %v", want, got)
+	}
+
+	r.Push(5)
+	r.Push(6)
+	if want, got := []int{5, 6}, receive(t, ch, 2); !reflect.DeepEqual(want, got) {
+		t.Errorf("stream, want: %v, got: %v", want, got)
+	}
+	if got := r.Subscribers(); got != 1 {
+		t.Errorf("want: 1, got: %v", got)
+	}
+
+	cancel()
+	waitClosed(t, ch)
+	if got := r.Subscribers(); got != 0 {
+		t.Errorf("want: 0, got: %v", got)
+	}
+
+	// cancelled context only replays
+	ch = r.Subscribe(ctx)
+	if want, got := []int{4, 5, 6}, receive(t, ch, 3); !reflect.DeepEqual(want, got) {
+		t.Errorf("want: %v, got: %v", want, got)
+	}
+	waitClosed(t, ch)
+}
+
+func TestRingSubscribeSlow(t *testing.T) {
+	r := NewRing[int](10)
+	r.Push(0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	drop := r.Subscribe(ctx, SubscribeOptionBuffer(2))
+	disconnect := r.Subscribe(ctx, SubscribeOptionBuffer(2), SubscribeOptionPolicy(SlowSubscriberDisconnect))
+
+	for i := 1; i <= 4; i++ {
+		r.Push(i) // never blocks
+	}
+
+	if want, got := []int{0, 1, 2}, receive(t, drop, 3); !reflect.DeepEqual(want, got) {
+		t.Errorf("drop, want: %v, got: %v", want, got)
+	}
+	r.Push(5)
+	if want, got := []int{5}, receive(t, drop, 1); !reflect.DeepEqual(want, got) {
+		t.Errorf("drop, want: %v, got: %v", want, got)
+	}
+
+	if want, got := []int{0, 1, 2}, receive(t, disconnect, 3); !reflect.DeepEqual(want, got) {
+		t.Errorf("disconnect, want: %v, got: %v", want, got)
+	}
+	waitClosed(t, disconnect)
+	if got := r.Subscribers(); got != 1 {
+		t.Errorf("want: 1, got: %v", got)
+	}
+}
+
+func TestRingUnsubscribe(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	r := NewRing[int](3)
+	r.Push(1)
+
+	// no goroutine for a ctx which is never done
+	ch := r.Subscribe(context.Background())
+	if got := runtime.NumGoroutine(); got != before {
+		t.Errorf("want: %d goroutines, got: %d", before, got)
+	}
+	if want, got := []int{1}, receive(t, ch, 1); !reflect.DeepEqual(want, got) {
+		t.Errorf("want: %v, got: %v", want, got)
+	}
+
+	if !r.Unsubscribe(ch) {
+		t.Errorf("want: true, got: false")
+	}
+	waitClosed(t, ch)
+	if r.Unsubscribe(ch) {
+		t.Errorf("want: false, got: true")
+	}
+	if got := r.Subscribers(); got != 0 {
+		t.Errorf("want: 0, got: %v", got)
+	}
+	r.Push(2) // no panic on closed channel
+
+	// Unsubscribe stops the ctx watcher
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch = r.Subscribe(ctx)
+	r.Unsubscribe(ch)
+	waitClosed(t, ch)
+
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before {
+		if time.Now().After(deadline) {
+			t.Fatalf("want no goroutine leak, got: %d > %d", runtime.NumGoroutine(), before)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestRingSubscribeConcurrent(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	r := NewRing[int](16)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				r.Push(i*1000 + j)
+			}
+		}(i)
+	}
+
+	subs := sync.WaitGroup{}
+	for i := 0; i < 4; i++ {
+		policy := SlowSubscriberPolicy(i % 2)
+		ch := r.Subscribe(ctx, SubscribeOptionBuffer(8), SubscribeOptionPolicy(policy))
+		subs.Add(1)
+		go func() {
+			defer subs.Done()
+			for range ch {
+			}
+		}()
+	}
+
+	wg.Wait()
+	cancel()
+	subs.Wait()
+
+	deadline := time.Now().Add(time.Second)
+	for r.Subscribers() != 0 || runtime.NumGoroutine() > before {
+		if time.Now().After(deadline) {
+			t.Fatalf("want no subscriber and goroutine leak, got: %d %d > %d",
+				r.Subscribers(), runtime.NumGoroutine(), before)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -2,6 +2,7 @@ package chper
 
 import (
 	"reflect"
+	"sync"
 	"testing"
 )
 
@@ -134,3 +135,400 @@ func TestRing(t *testing.T) {
 		}
 	}
 }
+
+func TestRingPop(t *testing.T) {
+	ring := NewRing[int](3)
+
+	if _, ok := ring.PopFront(); ok {
+		t.Errorf("want: false, got: %v", ok)
+	}
+	if _, ok := ring.PopBack(); ok {
+		t.Errorf("want: false, got: %v", ok)
+	}
+
+	for i := 1; i <= 4; i++ {
+		ring.Push(i)
+	}
+	// [2] 3 4, begin is not 0
+
+	v, ok := ring.PopFront()
+	if !ok || v != 2 {
+		t.Errorf("want: 2, got: %v, %v", v, ok)
+	}
+	v, ok = ring.PopBack()
+	if !ok || v != 4 {
+		t.Errorf("want: 4, got: %v, %v", v, ok)
+	}
+
+	// push after pop wrap around
+	ring.Push(5)
+	ring.Push(6)
+	got := ring.Elements(nil)
+	want := []int{3, 5, 6}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want: %v, got: %v", want, got)
+	}
+	ring.Push(7)
+	got = ring.Elements(nil)
+	want = []int{5, 6, 7}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want: %v, got: %v", want, got)
+	}
+
+	last, _ := ring.Last()
+	first, _ := ring.First()
+	if first != 5 || last != 7 {
+		t.Errorf("want: 5 7, got: %v %v", first, last)
+	}
+
+	for _, want := range []int{5, 6, 7} {
+		v, ok := ring.PopFront()
+		if !ok || v != want {
+			t.Errorf("want: %v, got: %v, %v", want, v, ok)
+		}
+	}
+	if ring.Size() != 0 {
+		t.Errorf("want: 0, got: %v", ring.Size())
+	}
+	if _, ok := ring.First(); ok {
+		t.Errorf("want: false, got: %v", ok)
+	}
+
+	// removed slots are zeroed
+	if want := []int{0, 0, 0}; !reflect.DeepEqual(ring.elements, want) {
+		t.Errorf("want: %v, got: %v", want, ring.elements)
+	}
+}
+
+func TestRingDrainPeek(t *testing.T) {
+	ring := NewRing[int](5)
+	for i := 0; i < 7; i++ {
+		ring.Push(i)
+	}
+	// 5 6 [2] 3 4
+
+	for _, cas := range []struct {
+		n    int
+		want []int
+	}{
+		{n: 0, want: []int{}},
+		{n: 2, want: []int{2, 3}},
+		{n: 10, want: []int{2, 3, 4, 5, 6}},
+		{n: -1, want: []int{2, 3, 4, 5, 6}},
+	} {
+		if got := ring.PeekN(cas.n); !reflect.DeepEqual(got, cas.want) {
+			t.Errorf("PeekN(%d), want: %v, got: %v", cas.n, cas.want, got)
+		}
+	}
+	if ring.Size() != 5 {
+		t.Errorf("want: 5, got: %v", ring.Size())
+	}
+
+	got := ring.Drain(2)
+	want := []int{2, 3}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want: %v, got: %v", want, got)
+	}
+	got = ring.Drain(-1)
+	want = []int{4, 5, 6}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want: %v, got: %v", want, got)
+	}
+	got = ring.Drain(1)
+	want = []int{}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want: %v, got: %v", want, got)
+	}
+	if want := []int{0, 0, 0, 0, 0}; !reflect.DeepEqual(ring.elements, want) {
+		t.Errorf("want: %v, got: %v", want, ring.elements)
+	}
+}
+
+func TestRingClear(t *testing.T) {
+	ring := NewRing[*Node](3)
+	ring.Push(nodeA)
+	ring.Push(nodeB)
+	ring.Push(nodeC)
+	ring.Push(nodeD)
+
+	ring.Clear()
+	if ring.Size() != 0 {
+		t.Errorf("want: 0, got: %v", ring.Size())
+	}
+	if got := ring.Elements(nil); got != nil {
+		t.Errorf("want: nil, got: %v", got)
+	}
+	for _, v := range ring.elements {
+		if v != nil {
+			t.Errorf("want nil, got: %v", v)
+		}
+	}
+
+	ring.Push(nodeA)
+	if got, want := ring.Elements(nil), []*Node{nodeA}; !reflect.DeepEqual(got, want) {
+		t.Errorf("want: %v, got: %v", want, got)
+	}
+}
+
+func TestRingAt(t *testing.T) {
+	ring := NewRing[int](4)
+	if _, ok := ring.At(0); ok {
+		t.Errorf("want: false, got: %v", ok)
+	}
+
+	for i := 0; i < 6; i++ {
+		ring.Push(i)
+	}
+	// 4 5 [2] 3
+
+	for _, cas := range []struct {
+		i      int
+		want   int
+		wantOk bool
+	}{
+		{i: 0, want: 2, wantOk: true},
+		{i: 1, want: 3, wantOk: true},
+		{i: 2, want: 4, wantOk: true},
+		{i: 3, want: 5, wantOk: true},
+		{i: 4, want: 0, wantOk: false},
+		{i: -1, want: 5, wantOk: true},
+		{i: -4, want: 2, wantOk: true},
+		{i: -5, want: 0, wantOk: false},
+	} {
+		got, ok := ring.At(cas.i)
+		if got != cas.want || ok != cas.wantOk {
+			t.Errorf("At(%d), want: %v %v, got: %v %v", cas.i, cas.want, cas.wantOk, got, ok)
+		}
+	}
+}
+
+func TestRingRange(t *testing.T) {
+	ring := NewRing[int](4)
+	for i := 0; i < 6; i++ {
+		ring.Push(i)
+	}
+
+	type pair struct{ i, v int }
+
+	got := []pair{}
+	ring.Range(func(i, v int) bool {
+		got = append(got, pair{i, v})
+		return true
+	})
+	want := []pair{{0, 2}, {1, 3}, {2, 4}, {3, 5}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want: %v, got: %v", want, got)
+	}
+
+	got = []pair{}
+	ring.Range(func(i, v int) bool {
+		got = append(got, pair{i, v})
+		return i < 1
+	})
+	want = []pair{{0, 2}, {1, 3}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want: %v, got: %v", want, got)
+	}
+
+	got = []pair{}
+	ring.RangeReverse(func(i, v int) bool {
+		got = append(got, pair{i, v})
+		return v > 4
+	})
+	want = []pair{{3, 5}, {2, 4}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want: %v, got: %v", want, got)
+	}
+}
+
+func TestRingIter(t *testing.T) {
+	ring := NewRing[string](3)
+	for _, s := range []string{"a", "b", "c", "d"} {
+		ring.Push(s)
+	}
+
+	type pair struct {
+		i int
+		v string
+	}
+
+	got := []pair{}
+	for i, v := range ring.All() {
+		got = append(got, pair{i, v})
+	}
+	want := []pair{{0, "b"}, {1, "c"}, {2, "d"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want: %v, got: %v", want, got)
+	}
+
+	values := []string{}
+	for v := range ring.Values() {
+		if v == "d" {
+			break
+		}
+		values = append(values, v)
+	}
+	if want := []string{"b", "c"}; !reflect.DeepEqual(values, want) {
+		t.Errorf("want: %v, got: %v", want, values)
+	}
+
+	got = []pair{}
+	for i, v := range ring.Backward() {
+		got = append(got, pair{i, v})
+	}
+	want = []pair{{2, "d"}, {1, "c"}, {0, "b"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want: %v, got: %v", want, got)
+	}
+
+	// lock is released after break
+	ring.Push("e")
+	if got := ring.Elements(nil); !reflect.DeepEqual(got, []string{"c", "d", "e"}) {
+		t.Errorf("want: %v, got: %v", []string{"c", "d", "e"}, got)
+	}
+}
+
+func TestRingResize(t *testing.T) {
+	newRing := func() *Ring[int] {
+		ring := NewRing[int](4)
+		for i := 0; i < 6; i++ {
+			ring.Push(i)
+		}
+		// 4 5 [2] 3, begin is 2
+		return ring
+	}
+
+	for _, cas := range []struct {
+		name     string
+		capacity int
+		want     []int
+		push     int
+		wantPush []int
+	}{
+		{name: "same", capacity: 4, want: []int{2, 3, 4, 5}, push: 6, wantPush: []int{3, 4, 5, 6}},
+		{name: "grow", capacity: 6, want: []int{2, 3, 4, 5}, push: 6, wantPush: []int{2, 3, 4, 5, 6}},
+		{name: "shrink", capacity: 2, want: []int{4, 5}, push: 6, wantPush: []int{5, 6}},
+		{name: "shrink to one", capacity: 1, want: []int{5}, push: 6, wantPush: []int{6}},
+	} {
+		ring := newRing()
+		ring.Resize(cas.capacity)
+		if got := ring.Elements(nil); !reflect.DeepEqual(got, cas.want) {
+			t.Errorf("%s, want: %v, got: %v", cas.name, cas.want, got)
+		}
+		if got := ring.Capacity(); got != cas.capacity {
+			t.Errorf("%s, want: %v, got: %v", cas.name, cas.capacity, got)
+		}
+		ring.Push(cas.push)
+		if got := ring.Elements(nil); !reflect.DeepEqual(got, cas.wantPush) {
+			t.Errorf("%s, want: %v, got: %v", cas.name, cas.wantPush, got)
+		}
+	}
+
+	// pop makes begin not 0 while ring is not filled
+	{
+		ring := NewRing[int](4)
+		for i := 0; i < 4; i++ {
+			ring.Push(i)
+		}
+		ring.PopFront()
+		ring.PopFront()
+		ring.Push(4)
+		// 4 _ [2] 3
+		ring.Resize(5)
+		if got, want := ring.Elements(nil), []int{2, 3, 4}; !reflect.DeepEqual(got, want) {
+			t.Errorf("want: %v, got: %v", want, got)
+		}
+		if last, _ := ring.Last(); last != 4 {
+			t.Errorf("want: 4, got: %v", last)
+		}
+	}
+
+	// empty ring
+	{
+		ring := NewRing[int](2)
+		ring.Resize(3)
+		if ring.Size() != 0 || ring.Capacity() != 3 {
+			t.Errorf("want: 0 3, got: %v %v", ring.Size(), ring.Capacity())
+		}
+	}
+
+	// concurrent pushes are not lost
+	{
+		ring := NewRing[int](1000)
+		var wg sync.WaitGroup
+		for g := 0; g < 4; g++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < 200; i++ {
+					ring.Push(i)
+				}
+			}()
+		}
+		for c := 900; c <= 1000; c += 10 {
+			ring.Resize(c)
+		}
+		wg.Wait()
+		if got := ring.Size(); got != 800 {
+			t.Errorf("want: 800, got: %v", got)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("want panic")
+		}
+	}()
+	newRing().Resize(0)
+}
+
+func TestRingOnEvict(t *testing.T) {
+	evicted := []int{}
+	var ring *Ring[int]
+	ring = NewRing[int](3, RingOptionOnEvict(func(v int) {
+		evicted = append(evicted, v)
+		// called outside the lock
+		ring.Size()
+	}))
+
+	for i := 0; i < 5; i++ {
+		ring.Push(i)
+	}
+	if want := []int{0, 1}; !reflect.DeepEqual(evicted, want) {
+		t.Errorf("want: %v, got: %v", want, evicted)
+	}
+
+	// pop and clear are not evictions
+	ring.PopFront()
+	ring.PopBack()
+	if want := []int{0, 1}; !reflect.DeepEqual(evicted, want) {
+		t.Errorf("want: %v, got: %v", want, evicted)
+	}
+
+	for i := 5; i < 8; i++ {
+		ring.Push(i)
+	}
+	// 3 5 6, push 7 evict 3
+	ring.Resize(1)
+	// 5 6 7, resize evict 5 6
+	if want := []int{0, 1, 3, 5, 6}; !reflect.DeepEqual(evicted, want) {
+		t.Errorf("want: %v, got: %v", want, evicted)
+	}
+
+	got := ring.Stats()
+	want := RingStats{Pushed: 8, Evicted: 5, HighWater: 3}
+	if got != want {
+		t.Errorf("want: %+v, got: %+v", want, got)
+	}
+
+	ring.Clear()
+	ring.Resize(10)
+	for i := 0; i < 6; i++ {
+		ring.Push(i)
+	}
+	got = ring.Stats()
+	want = RingStats{Pushed: 14, Evicted: 5, HighWater: 6}
+	if got != want {
+		t.Errorf("want: %+v, got: %+v", want, got)
+	}
+}
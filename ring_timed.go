@@ -0,0 +1,134 @@
+package chper
+
+import (
+	"sort"
+	"time"
+)
+
+// TimedRing is a Ring whose elements expire after ttl
+// every element gets a timestamp when it is pushed, expired elements are dropped lazily when reading
+// timestamps are assumed to be non-decreasing, which is true if the clock is monotonic
+type TimedRing[V any] struct {
+	ring *Ring[timedElement[V]]
+
+	ttl time.Duration
+	now func() time.Time
+}
+
+type timedElement[V any] struct {
+	at    time.Time
+	value V
+}
+
+type timedRingOption struct {
+	now func() time.Time
+}
+
+type timedRingOptionFunc func(*timedRingOption)
+
+// TimedRingOptionClock specify the clock, default is time.Now
+func TimedRingOptionClock(now func() time.Time) timedRingOptionFunc {
+	return func(to *timedRingOption) {
+		to.now = now
+	}
+}
+
+// NewTimedRing create a TimedRing which keeps at most capacity elements pushed in the last ttl
+func NewTimedRing[V any](capacity int, ttl time.Duration, options ...timedRingOptionFunc) *TimedRing[V] {
+	if ttl <= 0 {
+		panic("bad ttl")
+	}
+
+	option := &timedRingOption{now: time.Now}
+	for _, f := range options {
+		f(option)
+	}
+
+	return &TimedRing[V]{
+		ring: NewRing[timedElement[V]](capacity),
+		ttl:  ttl,
+		now:  option.now,
+	}
+}
+
+// Push append one element with current time
+func (tr *TimedRing[V]) Push(data V) {
+	tr.ring.lock.Lock()
+	tr.ring.push(timedElement[V]{at: tr.now(), value: data})
+	tr.ring.lock.Unlock()
+}
+
+// expire drop expired elements, it must be called with ring.lock held
+func (tr *TimedRing[V]) expire() {
+	deadline := tr.now().Add(-tr.ttl)
+	for tr.ring.size > 0 && tr.ring.at(0).at.Before(deadline) {
+		tr.ring.popFront()
+	}
+}
+
+// Elements return matched unexpired elements, sorted by push index
+func (tr *TimedRing[V]) Elements(filter func(V) bool) (elements []V) {
+	tr.ring.lock.Lock()
+	defer tr.ring.lock.Unlock()
+
+	tr.expire()
+	for i := 0; i < tr.ring.size; i++ {
+		ele := tr.ring.at(i).value
+		if filter == nil || filter(ele) {
+			elements = append(elements, ele)
+		}
+	}
+
+	return
+}
+
+// Since return unexpired elements pushed at or after t, sorted by push index
+func (tr *TimedRing[V]) Since(t time.Time) (elements []V) {
+	tr.ring.lock.Lock()
+	defer tr.ring.lock.Unlock()
+
+	tr.expire()
+	begin := sort.Search(tr.ring.size, func(i int) bool {
+		return !tr.ring.at(i).at.Before(t)
+	})
+	for i := begin; i < tr.ring.size; i++ {
+		elements = append(elements, tr.ring.at(i).value)
+	}
+
+	return
+}
+
+// First return first unexpired element and exist
+func (tr *TimedRing[V]) First() (v V, ok bool) {
+	tr.ring.lock.Lock()
+	defer tr.ring.lock.Unlock()
+
+	tr.expire()
+	if tr.ring.size == 0 {
+		return
+	}
+
+	return tr.ring.at(0).value, true
+}
+
+// Last return last unexpired element and exist
+func (tr *TimedRing[V]) Last() (v V, ok bool) {
+	tr.ring.lock.Lock()
+	defer tr.ring.lock.Unlock()
+
+	tr.expire()
+	if tr.ring.size == 0 {
+		return
+	}
+
+	return tr.ring.at(tr.ring.size - 1).value, true
+}
+
+// Size return unexpired elements count
+func (tr *TimedRing[V]) Size() int {
+	tr.ring.lock.Lock()
+	defer tr.ring.lock.Unlock()
+
+	tr.expire()
+	return tr.ring.size
+}
@@ -0,0 +1,96 @@
+package chper
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (fc *fakeClock) Now() time.Time { return fc.now }
+
+func (fc *fakeClock) Add(d time.Duration) { fc.now = fc.now.Add(d) }
+
+func TestTimedRing(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	begin := clock.Now()
+	ring := NewTimedRing[int](5, 5*time.Minute, TimedRingOptionClock(clock.Now))
+
+	if _, ok := ring.First(); ok {
+		t.Errorf("want: false, got: %v", ok)
+	}
+	if _, ok := ring.Last(); ok {
+		t.Errorf("want: false, got: %v", ok)
+	}
+
+	// push one element every minute
+	for i := 0; i < 4; i++ {
+		ring.Push(i)
+		clock.Add(time.Minute)
+	}
+	// now is begin+4m, elements are pushed at 0m 1m 2m 3m
+
+	got := ring.Elements(nil)
+	want := []int{0, 1, 2, 3}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want: %v, got: %v", want, got)
+	}
+	got = ring.Elements(func(i int) bool { return i%2 == 1 })
+	want = []int{1, 3}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want: %v, got: %v", want, got)
+	}
+
+	for _, cas := range []struct {
+		since time.Time
+		want  []int
+	}{
+		{since: begin.Add(-time.Minute), want: []int{0, 1, 2, 3}},
+		{since: begin, want: []int{0, 1, 2, 3}},
+		{since: begin.Add(90 * time.Second), want: []int{2, 3}},
+		{since: begin.Add(3 * time.Minute), want: []int{3}},
+		{since: begin.Add(time.Hour), want: nil},
+	} {
+		if got := ring.Since(cas.since); !reflect.DeepEqual(got, cas.want) {
+			t.Errorf("since: %v, want: %v, got: %v", cas.since, cas.want, got)
+		}
+	}
+
+	// 0 and 1 expired
+	clock.Add(2*time.Minute + time.Second)
+	got = ring.Elements(nil)
+	want = []int{2, 3}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want: %v, got: %v", want, got)
+	}
+	if first, _ := ring.First(); first != 2 {
+		t.Errorf("want: 2, got: %v", first)
+	}
+	if last, _ := ring.Last(); last != 3 {
+		t.Errorf("want: 3, got: %v", last)
+	}
+	if ring.Size() != 2 {
+		t.Errorf("want: 2, got: %v", ring.Size())
+	}
+
+	// at most capacity elements
+	for i := 10; i < 17; i++ {
+		ring.Push(i)
+	}
+	got = ring.Elements(nil)
+	want = []int{12, 13, 14, 15, 16}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want: %v, got: %v", want, got)
+	}
+
+	clock.Add(time.Hour)
+	if ring.Size() != 0 {
+		t.Errorf("want: 0, got: %v", ring.Size())
+	}
+	if got := ring.Elements(nil); got != nil {
+		t.Errorf("want: nil, got: %v", got)
+	}
+}